@@ -0,0 +1,189 @@
+package gorq
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/filters"
+)
+
+// checkedExpression is a filters.DialectWrapper that may fail to
+// generate its SQL, e.g. because of an unsupported unit.
+type checkedExpression func(dialect gorp.Dialect) (interface{}, error)
+
+func (e checkedExpression) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	return e(dialect)
+}
+
+// DateTrunc returns a filters.DialectWrapper for value truncated to
+// the start of unit, which must be one of "year", "month", "week",
+// "day", "hour", "minute", or "second".  Weeks start on Monday.
+// Example:
+//
+//     results, err := dbMap.Query(ref).
+//         Where().
+//         Equal(gorq.DateTrunc("month", &ref.CreatedAt), month).
+//         Select()
+//
+// SQLite has no date types, so it returns the truncated date as text
+// in UTC, e.g. "2016-02-01 00:00:00".
+func DateTrunc(unit string, value interface{}) filters.DialectWrapper {
+	return filters.DateTrunc(unit, value)
+}
+
+// sqliteExtractFormats are the strftime formats used to extract parts
+// of dates in SQLite.
+var sqliteExtractFormats = map[string]string{
+	"year":   "%Y",
+	"month":  "%m",
+	"day":    "%d",
+	"hour":   "%H",
+	"minute": "%M",
+	"second": "%S",
+	"dow":    "%w",
+	"doy":    "%j",
+}
+
+// Extract returns a filters.DialectWrapper for part of value as an
+// integer.  The part must be one of "year", "month", "day", "hour",
+// "minute", "second", "dow" (the day of the week, where Sunday is 0),
+// or "doy" (the day of the year).  Fractional seconds are dropped.
+func Extract(part string, value interface{}) filters.DialectWrapper {
+	part = strings.ToLower(part)
+	return checkedExpression(func(dialect gorp.Dialect) (interface{}, error) {
+		if _, ok := sqliteExtractFormats[part]; !ok {
+			return nil, fmt.Errorf("gorp: Extract does not support the part %q", part)
+		}
+		switch dialect.(type) {
+		case dialects.MySQLDialect:
+			switch part {
+			case "dow":
+				return filters.FormatWrapper{
+					Values: []interface{}{value},
+					Format: func(sqlValues ...string) string {
+						return "(DAYOFWEEK(" + sqlValues[0] + ") - 1)"
+					},
+				}, nil
+			case "doy":
+				return filters.Function("DAYOFYEAR", value), nil
+			}
+			return filters.FormatWrapper{
+				Values: []interface{}{value},
+				Format: func(sqlValues ...string) string {
+					return "EXTRACT(" + strings.ToUpper(part) + " FROM " + sqlValues[0] + ")"
+				},
+			}, nil
+		case dialects.SqliteDialect:
+			return filters.FormatWrapper{
+				Values: []interface{}{value},
+				Format: func(sqlValues ...string) string {
+					return "CAST(strftime('" + sqliteExtractFormats[part] + "', " + sqlValues[0] + ") AS INTEGER)"
+				},
+			}, nil
+		default:
+			// EXTRACT returns a fractional number in PostgreSQL.
+			return filters.FormatWrapper{
+				Values: []interface{}{value},
+				Format: func(sqlValues ...string) string {
+					return "CAST(FLOOR(EXTRACT(" + strings.ToUpper(part) + " FROM " + sqlValues[0] + ")) AS integer)"
+				},
+			}, nil
+		}
+	})
+}
+
+// Now returns a filters.MultiSqlWrapper for the current date and
+// time.  In PostgreSQL, this is the time that the current transaction
+// started.
+func Now() filters.MultiSqlWrapper {
+	return filters.FormatWrapper{
+		Format: func(sqlValues ...string) string {
+			return "CURRENT_TIMESTAMP"
+		},
+	}
+}
+
+// intervalUnits are the units supported by AddInterval and
+// SubInterval, mapped to the SQLite modifier for each.
+var intervalUnits = map[string]string{
+	"year":   "years",
+	"month":  "months",
+	"week":   "days",
+	"day":    "days",
+	"hour":   "hours",
+	"minute": "minutes",
+	"second": "seconds",
+}
+
+// interval returns an expression that adds (or, if negate is true,
+// subtracts) amount units to value.
+func interval(name string, value, amount interface{}, unit string, negate bool) filters.DialectWrapper {
+	unit = strings.ToLower(unit)
+	return checkedExpression(func(dialect gorp.Dialect) (interface{}, error) {
+		modifier, ok := intervalUnits[unit]
+		if !ok {
+			return nil, fmt.Errorf("gorp: %s does not support the unit %q", name, unit)
+		}
+		switch dialect.(type) {
+		case dialects.MySQLDialect:
+			mysqlFunc := "DATE_ADD"
+			if negate {
+				mysqlFunc = "DATE_SUB"
+			}
+			return filters.FormatWrapper{
+				Values: []interface{}{value, amount},
+				Format: func(sqlValues ...string) string {
+					return mysqlFunc + "(" + sqlValues[0] + ", INTERVAL " + sqlValues[1] + " " + strings.ToUpper(unit) + ")"
+				},
+			}, nil
+		case dialects.SqliteDialect:
+			return filters.FormatWrapper{
+				Values: []interface{}{value, amount},
+				Format: func(sqlValues ...string) string {
+					count := "(" + sqlValues[1] + ")"
+					if unit == "week" {
+						count += " * 7"
+					}
+					if negate {
+						count = "-" + count
+					}
+					return "datetime(" + sqlValues[0] + ", CAST(" + count + " AS TEXT) || ' " + modifier + "')"
+				},
+			}, nil
+		default:
+			op := " + "
+			if negate {
+				op = " - "
+			}
+			return filters.FormatWrapper{
+				Values: []interface{}{value, amount},
+				Format: func(sqlValues ...string) string {
+					return "(" + sqlValues[0] + op + sqlValues[1] + " * INTERVAL '1 " + unit + "')"
+				},
+			}, nil
+		}
+	})
+}
+
+// AddInterval returns a filters.DialectWrapper for value plus amount
+// units, where unit is one of "year", "month", "week", "day", "hour",
+// "minute", or "second".  Like value, amount may be a field pointer,
+// another wrapper, or a value to bind as an argument.  Example:
+//
+//     results, err := dbMap.Query(ref).
+//         Where().
+//         Less(gorq.AddInterval(&ref.CreatedAt, 30, "day"), gorq.Now()).
+//         Select()
+//
+// SQLite returns the result as text in UTC, e.g. "2016-02-01 12:00:00".
+func AddInterval(value, amount interface{}, unit string) filters.DialectWrapper {
+	return interval("AddInterval", value, amount, unit, false)
+}
+
+// SubInterval returns a filters.DialectWrapper for value minus amount
+// units.  See AddInterval for the supported units.
+func SubInterval(value, amount interface{}, unit string) filters.DialectWrapper {
+	return interval("SubInterval", value, amount, unit, true)
+}
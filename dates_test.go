@@ -0,0 +1,60 @@
+package gorq
+
+import (
+	"testing"
+
+	"github.com/go-gorp/gorp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDateDialects(t *testing.T) {
+	testDialects(t, []dialectTest{
+		{"DateTrunc", DateTrunc("Month", "a"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "date_trunc('month', a)",
+			mysql:    "CAST(DATE_FORMAT(a, '%Y-%m-01 00:00:00') AS DATETIME)",
+			sqlite:   "strftime('%Y-%m-01 00:00:00', a)",
+		}},
+		{"DateTruncWeek", DateTrunc("week", "a"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "date_trunc('week', a)",
+			sqlite:   "strftime('%Y-%m-%d 00:00:00', a, 'weekday 0', '-6 days')",
+		}},
+		{"DateTruncWeekMySQL", DateTrunc("week", "a"), []string{"a", "b"}, map[gorp.Dialect]string{
+			mysql: "CAST(DATE_SUB(DATE(a), INTERVAL WEEKDAY(b) DAY) AS DATETIME)",
+		}},
+		{"Extract", Extract("year", "a"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "CAST(FLOOR(EXTRACT(YEAR FROM a)) AS integer)",
+			mysql:    "EXTRACT(YEAR FROM a)",
+			sqlite:   "CAST(strftime('%Y', a) AS INTEGER)",
+		}},
+		{"ExtractDow", Extract("dow", "a"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "CAST(FLOOR(EXTRACT(DOW FROM a)) AS integer)",
+			mysql:    "(DAYOFWEEK(a) - 1)",
+			sqlite:   "CAST(strftime('%w', a) AS INTEGER)",
+		}},
+		{"Now", Now(), []string{}, map[gorp.Dialect]string{
+			postgres: "CURRENT_TIMESTAMP", mysql: "CURRENT_TIMESTAMP", sqlite: "CURRENT_TIMESTAMP",
+		}},
+		{"AddInterval", AddInterval("a", "b", "day"), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "(a + b * INTERVAL '1 day')",
+			mysql:    "DATE_ADD(a, INTERVAL b DAY)",
+			sqlite:   "datetime(a, CAST((b) AS TEXT) || ' days')",
+		}},
+		{"SubInterval", SubInterval("a", "b", "week"), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "(a - b * INTERVAL '1 week')",
+			mysql:    "DATE_SUB(a, INTERVAL b WEEK)",
+			sqlite:   "datetime(a, CAST(-(b) * 7 AS TEXT) || ' days')",
+		}},
+	})
+}
+
+func TestDateUnitValidation(t *testing.T) {
+	dialect := gorp.PostgresDialect{}
+	_, err := DateTrunc("decade'); --", "a").ForDialect(dialect)
+	assert.Error(t, err)
+	_, err = Extract("epoch", "a").ForDialect(dialect)
+	assert.Error(t, err)
+	_, err = AddInterval("a", 1, "fortnight").ForDialect(dialect)
+	assert.Error(t, err)
+	_, err = SubInterval("a", 1, "").ForDialect(dialect)
+	assert.Error(t, err)
+}
@@ -0,0 +1,26 @@
+package gorq
+
+import "github.com/nelsam/gorq/interfaces"
+
+// Asc and Desc are the directions that can be passed to OrderBy.  To
+// control where null values are sorted, use NullsFirst and NullsLast:
+//
+//     results, err := dbMap.Query(ref).
+//         OrderBy(&ref.DueDate, gorq.NullsLast(gorq.Desc)).
+//         Select()
+const (
+	Asc  = interfaces.Asc
+	Desc = interfaces.Desc
+)
+
+// NullsFirst returns direction, modified to sort null values before
+// all other values.
+func NullsFirst(direction string) string {
+	return interfaces.NullsFirst(direction)
+}
+
+// NullsLast returns direction, modified to sort null values after
+// all other values.
+func NullsLast(direction string) string {
+	return interfaces.NullsLast(direction)
+}
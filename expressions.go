@@ -0,0 +1,206 @@
+package gorq
+
+import (
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/filters"
+)
+
+// operator returns an expression that joins values with an infix
+// operator.
+func operator(op string, values ...interface{}) filters.FormatWrapper {
+	return filters.FormatWrapper{
+		Values: values,
+		Format: func(sqlValues ...string) string {
+			return "(" + strings.Join(sqlValues, " "+op+" ") + ")"
+		},
+	}
+}
+
+// dialectExpression is a filters.DialectWrapper that chooses its SQL
+// once the dialect is known.
+type dialectExpression func(dialect gorp.Dialect) interface{}
+
+func (e dialectExpression) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	return e(dialect), nil
+}
+
+// Add returns a filters.MultiSqlWrapper for left + right.  Like all
+// of the expression builders in this package, either value may be a
+// field pointer, another wrapper, or a value to bind as an argument,
+// and the result can be used anywhere a field pointer can: in
+// filters, OrderBy, GroupBy, Assign, etc.  Example:
+//
+//     count, err := dbMap.Query(ref).
+//         Assign(&ref.Views, Add(&ref.Views, 1)).
+//         Where().
+//         Equal(&ref.Id, id).
+//         Update()
+func Add(left, right interface{}) filters.MultiSqlWrapper {
+	return operator("+", left, right)
+}
+
+// Sub returns a filters.MultiSqlWrapper for left - right.
+func Sub(left, right interface{}) filters.MultiSqlWrapper {
+	return operator("-", left, right)
+}
+
+// Mul returns a filters.MultiSqlWrapper for left * right.
+func Mul(left, right interface{}) filters.MultiSqlWrapper {
+	return operator("*", left, right)
+}
+
+// Div returns a filters.MultiSqlWrapper for left / right.  Note that
+// PostgreSQL and SQLite truncate the result of dividing two integers,
+// while MySQL does not.
+func Div(left, right interface{}) filters.MultiSqlWrapper {
+	return operator("/", left, right)
+}
+
+// Mod returns a filters.DialectWrapper for the remainder of left
+// divided by right.
+func Mod(left, right interface{}) filters.DialectWrapper {
+	return dialectExpression(func(dialect gorp.Dialect) interface{} {
+		if _, isSqlite := dialect.(dialects.SqliteDialect); isSqlite {
+			// SQLite only has mod() when built with math functions.
+			return operator("%", left, right)
+		}
+		return filters.Function("MOD", left, right)
+	})
+}
+
+// Concat returns a filters.DialectWrapper that concatenates values as
+// strings.  If any of the values is null, the result is null.
+func Concat(values ...interface{}) filters.DialectWrapper {
+	return dialectExpression(func(dialect gorp.Dialect) interface{} {
+		switch dialect.(type) {
+		case dialects.MySQLDialect:
+			// || is a logical OR in MySQL, by default.
+			return filters.Function("CONCAT", values...)
+		case dialects.SqliteDialect:
+			return operator("||", values...)
+		default:
+			// PostgreSQL can't resolve || for bind arguments of
+			// unknown type.
+			return filters.FormatWrapper{
+				Values: values,
+				Format: func(sqlValues ...string) string {
+					casts := make([]string, 0, len(sqlValues))
+					for _, sqlValue := range sqlValues {
+						casts = append(casts, "CAST("+sqlValue+" AS text)")
+					}
+					return "(" + strings.Join(casts, " || ") + ")"
+				},
+			}
+		}
+	})
+}
+
+// Upper returns a filters.SqlWrapper that wraps the passed in value
+// in an sql upper() call.
+func Upper(value interface{}) filters.SqlWrapper {
+	return functionWrapper{
+		actualValue:  value,
+		functionName: "upper",
+	}
+}
+
+// Trim returns a filters.SqlWrapper that wraps the passed in value
+// in an sql trim() call, removing leading and trailing spaces.
+func Trim(value interface{}) filters.SqlWrapper {
+	return functionWrapper{
+		actualValue:  value,
+		functionName: "trim",
+	}
+}
+
+// Length returns a filters.DialectWrapper for the number of
+// characters (not bytes) in value.
+func Length(value interface{}) filters.DialectWrapper {
+	return dialectExpression(func(dialect gorp.Dialect) interface{} {
+		if _, isMySQL := dialect.(dialects.MySQLDialect); isMySQL {
+			// LENGTH() counts bytes in MySQL.
+			return filters.Function("CHAR_LENGTH", value)
+		}
+		return filters.Function("length", value)
+	})
+}
+
+// Substring returns a filters.MultiSqlWrapper for the portion of
+// value starting at the (1-based) character position start.  If
+// length is nil, the rest of the string is returned; otherwise, at
+// most length characters are returned.
+func Substring(value, start, length interface{}) filters.MultiSqlWrapper {
+	if length == nil {
+		return filters.Function("substr", value, start)
+	}
+	return filters.Function("substr", value, start, length)
+}
+
+// Coalesce returns a filters.MultiSqlWrapper for the first non-null
+// value in values.
+func Coalesce(values ...interface{}) filters.MultiSqlWrapper {
+	return filters.Function("COALESCE", values...)
+}
+
+// NullIf returns a filters.MultiSqlWrapper that is null if value is
+// equal to compare, and value otherwise.
+func NullIf(value, compare interface{}) filters.MultiSqlWrapper {
+	return filters.Function("NULLIF", value, compare)
+}
+
+// Greatest returns a filters.DialectWrapper for the largest of values.
+// Note that PostgreSQL ignores null values, while MySQL and SQLite
+// return null if any value is null.
+func Greatest(values ...interface{}) filters.DialectWrapper {
+	return dialectExpression(func(dialect gorp.Dialect) interface{} {
+		if _, isSqlite := dialect.(dialects.SqliteDialect); isSqlite {
+			// SQLite's max() is a scalar function when given more
+			// than one argument.
+			return filters.Function("max", values...)
+		}
+		return filters.Function("GREATEST", values...)
+	})
+}
+
+// Least returns a filters.DialectWrapper for the smallest of values.
+// Null values are handled the same way as in Greatest.
+func Least(values ...interface{}) filters.DialectWrapper {
+	return dialectExpression(func(dialect gorp.Dialect) interface{} {
+		if _, isSqlite := dialect.(dialects.SqliteDialect); isSqlite {
+			return filters.Function("min", values...)
+		}
+		return filters.Function("LEAST", values...)
+	})
+}
+
+// Abs returns a filters.SqlWrapper that wraps the passed in value in
+// an sql abs() call.
+func Abs(value interface{}) filters.SqlWrapper {
+	return functionWrapper{
+		actualValue:  value,
+		functionName: "abs",
+	}
+}
+
+// Round returns a filters.DialectWrapper for value, rounded to places
+// decimal places.
+func Round(value interface{}, places int) filters.DialectWrapper {
+	return dialectExpression(func(dialect gorp.Dialect) interface{} {
+		switch dialect.(type) {
+		case dialects.MySQLDialect, dialects.SqliteDialect:
+			return filters.Function("round", value, places)
+		default:
+			// PostgreSQL can only round numeric values to a number
+			// of places.
+			return filters.FormatWrapper{
+				Values: []interface{}{value, places},
+				Format: func(sqlValues ...string) string {
+					return "round(CAST(" + sqlValues[0] + " AS numeric), " + sqlValues[1] + ")"
+				},
+			}
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package gorq
+
+import (
+	"testing"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/filters"
+	"github.com/stretchr/testify/assert"
+)
+
+// wrapSql renders wrapper for dialect, using sqlValues in place of
+// the wrapper's actual values.
+func wrapSql(t *testing.T, dialect gorp.Dialect, wrapper interface{}, sqlValues ...string) string {
+	if dialectWrapper, ok := wrapper.(filters.DialectWrapper); ok {
+		var err error
+		wrapper, err = dialectWrapper.ForDialect(dialect)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+	}
+	switch src := wrapper.(type) {
+	case filters.SqlWrapper:
+		return src.WrapSql(sqlValues[0])
+	case filters.MultiSqlWrapper:
+		assert.Equal(t, len(sqlValues), len(src.ActualValues()))
+		return src.WrapSql(sqlValues...)
+	}
+	t.Errorf("Unexpected wrapper type %T", wrapper)
+	return ""
+}
+
+// The dialects that dialectTests are checked against.
+var (
+	postgres = gorp.PostgresDialect{}
+	mysql    = dialects.MySQLDialect{}
+	sqlite   = dialects.SqliteDialect{}
+)
+
+// A dialectTest is a wrapper and the SQL that it should generate for
+// each dialect in expected, using values as the SQL for the wrapper's
+// actual values.
+type dialectTest struct {
+	name     string
+	wrapper  interface{}
+	values   []string
+	expected map[gorp.Dialect]string
+}
+
+// testDialects checks the SQL generated by each of tests.
+func testDialects(t *testing.T, tests []dialectTest) {
+	for _, test := range tests {
+		for dialect, expected := range test.expected {
+			assert.Equal(t, expected, wrapSql(t, dialect, test.wrapper, test.values...), "%s for %T", test.name, dialect)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	wrapper := Add("a", 1)
+	assert.Equal(t, []interface{}{"a", 1}, wrapper.ActualValues())
+	assert.Equal(t, "(a + b)", wrapper.WrapSql("a", "b"))
+	assert.Equal(t, "(a - b)", Sub("a", "b").WrapSql("a", "b"))
+	assert.Equal(t, "(a * b)", Mul("a", "b").WrapSql("a", "b"))
+	assert.Equal(t, "(a / b)", Div("a", "b").WrapSql("a", "b"))
+}
+
+func TestExpressionDialects(t *testing.T) {
+	testDialects(t, []dialectTest{
+		{"Mod", Mod("a", "b"), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "MOD(a, b)", mysql: "MOD(a, b)", sqlite: "(a % b)",
+		}},
+		{"Concat", Concat("a", "b", "c"), []string{"a", "b", "c"}, map[gorp.Dialect]string{
+			postgres: "(CAST(a AS text) || CAST(b AS text) || CAST(c AS text))",
+			mysql:    "CONCAT(a, b, c)",
+			sqlite:   "(a || b || c)",
+		}},
+		{"Upper", Upper("a"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "upper(a)", mysql: "upper(a)", sqlite: "upper(a)",
+		}},
+		{"Trim", Trim("a"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "trim(a)", mysql: "trim(a)", sqlite: "trim(a)",
+		}},
+		{"Length", Length("a"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "length(a)", mysql: "CHAR_LENGTH(a)", sqlite: "length(a)",
+		}},
+		{"Substring", Substring("a", 2, 3), []string{"a", "b", "c"}, map[gorp.Dialect]string{
+			postgres: "substr(a, b, c)", mysql: "substr(a, b, c)", sqlite: "substr(a, b, c)",
+		}},
+		{"SubstringToEnd", Substring("a", 2, nil), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "substr(a, b)", mysql: "substr(a, b)", sqlite: "substr(a, b)",
+		}},
+		{"Coalesce", Coalesce("a", "b"), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "COALESCE(a, b)", mysql: "COALESCE(a, b)", sqlite: "COALESCE(a, b)",
+		}},
+		{"NullIf", NullIf("a", "b"), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "NULLIF(a, b)", mysql: "NULLIF(a, b)", sqlite: "NULLIF(a, b)",
+		}},
+		{"Greatest", Greatest("a", "b"), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "GREATEST(a, b)", mysql: "GREATEST(a, b)", sqlite: "max(a, b)",
+		}},
+		{"Least", Least("a", "b"), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "LEAST(a, b)", mysql: "LEAST(a, b)", sqlite: "min(a, b)",
+		}},
+		{"Abs", Abs("a"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "abs(a)", mysql: "abs(a)", sqlite: "abs(a)",
+		}},
+		{"Round", Round("a", 2), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "round(CAST(a AS numeric), b)", mysql: "round(a, b)", sqlite: "round(a, b)",
+		}},
+		{"Func", Func("similarity", "a", "b"), []string{"a", "b"}, map[gorp.Dialect]string{
+			postgres: "similarity(a, b)", mysql: "similarity(a, b)", sqlite: "similarity(a, b)",
+		}},
+		{"Cast", Cast("a", "numeric(10, 2)"), []string{"a"}, map[gorp.Dialect]string{
+			postgres: "CAST(a AS numeric(10, 2))", mysql: "CAST(a AS numeric(10, 2))", sqlite: "CAST(a AS numeric(10, 2))",
+		}},
+		{"CastExpression", Cast(Raw("? || ?", "a", "b"), "integer"), []string{"a || b"}, map[gorp.Dialect]string{
+			postgres: "CAST(a || b AS integer)", mysql: "CAST(a || b AS integer)", sqlite: "CAST(a || b AS integer)",
+		}},
+	})
+}
+
+func TestFuncValidation(t *testing.T) {
+	dialect := gorp.PostgresDialect{}
+	for _, name := range []string{"date_part", "pg_catalog.lower", "_private"} {
+		_, err := Func(name, "a").(filters.DialectWrapper).ForDialect(dialect)
+		assert.NoError(t, err, name)
+	}
+	for _, name := range []string{"", "1abc", "lower(a); DROP TABLE users; --", "a b", "a.b.c"} {
+		_, err := Func(name, "a").(filters.DialectWrapper).ForDialect(dialect)
+		assert.Error(t, err, name)
+	}
+
+	allowed := FuncWhitelist("date_part", "Similarity")
+	_, err := allowed.Func("SIMILARITY", "a", "b").(filters.DialectWrapper).ForDialect(dialect)
+	assert.NoError(t, err)
+	_, err = allowed.Func("pg_sleep", 10).(filters.DialectWrapper).ForDialect(dialect)
+	assert.Error(t, err)
+	_, err = Func("pg_sleep", 10).(filters.DialectWrapper).ForDialect(dialect)
+	assert.NoError(t, err, "The whitelist should only apply to its own Func calls")
+}
+
+func TestCastValidation(t *testing.T) {
+	dialect := gorp.PostgresDialect{}
+	for _, sqlType := range []string{"integer", "double precision", "varchar(255)", "numeric(10,2)", "text[]",
+		"Timestamp With Time Zone", "character varying (20)"} {
+		_, err := Cast("a", sqlType).ForDialect(dialect)
+		assert.NoError(t, err, sqlType)
+	}
+	for _, sqlType := range []string{"", "int) FROM users; --", "text'", "int -- comment", "text or true",
+		"int union select password", "or", "FROM"} {
+		_, err := Cast("a", sqlType).ForDialect(dialect)
+		assert.Error(t, err, sqlType)
+	}
+}
@@ -1 +1,45 @@
-package extensions
+package extensions_test
+
+import (
+	"testing"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/extensions"
+	"github.com/nelsam/gorq/filters"
+	"github.com/nelsam/gorq/plans"
+	"github.com/stretchr/testify/assert"
+)
+
+type Document struct {
+	Id    int64
+	Title string
+	Body  string
+}
+
+func TestPostgresFullTextSearch(t *testing.T) {
+	dbMap := &gorp.DbMap{Dialect: gorp.PostgresDialect{}}
+	dbMap.AddTable(Document{}).SetKeys(true, "Id")
+	ref := new(Document)
+	plan := plans.Query(dbMap, dbMap, ref).
+		Where(filters.SearchWith(extensions.PostgresFullText{Config: "english"}, "quick fox", &ref.Title, &ref.Body)).(*plans.QueryPlan)
+
+	statement, err := plan.SelectStatement()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, statement.Query(),
+		` WHERE to_tsvector(%s::regconfig, coalesce("Document"."Title"::text, '') || ' ' || coalesce("Document"."Body"::text, '')) @@ plainto_tsquery(%s::regconfig, %s)`)
+	assert.Equal(t, []interface{}{"english", "english", "quick fox"}, statement.Args())
+}
+
+func TestPostgresFullTextSearchDialects(t *testing.T) {
+	for _, dialect := range []gorp.Dialect{gorp.MySQLDialect{}, gorp.SqliteDialect{}} {
+		dbMap := &gorp.DbMap{Dialect: dialect}
+		dbMap.AddTable(Document{}).SetKeys(true, "Id")
+		ref := new(Document)
+		plan := plans.Query(dbMap, dbMap, ref).
+			Where(filters.SearchWith(extensions.PostgresFullText{}, "quick fox", &ref.Title)).(*plans.QueryPlan)
+		_, err := plan.SelectStatement()
+		assert.Error(t, err, "%T should not support postgresql full-text search", dialect)
+	}
+}
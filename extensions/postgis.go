@@ -48,7 +48,7 @@ func (g *Geography) Scan(val interface{}) error {
 	case 1:
 		byteOrder = binary.LittleEndian
 	default:
-		return fmt.Errorf("invalid byte order %u", wkbByteOrder)
+		return fmt.Errorf("invalid byte order %d", wkbByteOrder)
 	}
 
 	var wkbGeometryType uint64
@@ -0,0 +1,73 @@
+package extensions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/filters"
+)
+
+// PostgresFullText is a filters.FullTextSearcher that uses
+// postgresql's full-text search, matching using text search vectors
+// instead of LIKE comparisons.  Pass it to filters.SearchWith along
+// with the fields to search:
+//
+//     english := extensions.PostgresFullText{Config: "english"}
+//     query.Where(filters.SearchWith(english, term, &ref.Title, &ref.Body))
+//
+// Queries using it will return an error for any other dialect.
+type PostgresFullText struct {
+	// Config is the text search configuration to use, e.g.
+	// "english".  If it is empty, "simple" will be used.
+	Config string
+}
+
+// FullTextSearch implements filters.FullTextSearcher.
+func (search PostgresFullText) FullTextSearch(term string, fieldPtrs ...interface{}) filters.Filter {
+	config := search.Config
+	if config == "" {
+		config = "simple"
+	}
+	return filters.Expression(fullTextSearch{config: config, term: term, fields: fieldPtrs})
+}
+
+// fullTextSearch is a filters.DialectWrapper that generates a
+// fullTextFilter for postgresql, and an error for other dialects.
+type fullTextSearch struct {
+	config string
+	term   string
+	fields []interface{}
+}
+
+func (s fullTextSearch) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	if _, ok := dialect.(gorp.PostgresDialect); !ok {
+		return nil, errors.New("gorp: PostgresFullText can only be used with postgresql")
+	}
+	return &fullTextFilter{config: s.config, term: s.term, fields: s.fields}, nil
+}
+
+type fullTextFilter struct {
+	config string
+	term   string
+	fields []interface{}
+}
+
+func (f *fullTextFilter) ActualValues() []interface{} {
+	values := make([]interface{}, 0, len(f.fields)+3)
+	values = append(values, f.config)
+	values = append(values, f.fields...)
+	return append(values, f.config, f.term)
+}
+
+func (f *fullTextFilter) Where(values ...string) string {
+	fieldCount := len(f.fields)
+	documents := make([]string, 0, fieldCount)
+	for _, field := range values[1 : fieldCount+1] {
+		documents = append(documents, fmt.Sprintf("coalesce(%s::text, '')", field))
+	}
+	queryConfig, term := values[fieldCount+1], values[fieldCount+2]
+	return fmt.Sprintf("to_tsvector(%s::regconfig, %s) @@ plainto_tsquery(%s::regconfig, %s)",
+		values[0], strings.Join(documents, " || ' ' || "), queryConfig, term)
+}
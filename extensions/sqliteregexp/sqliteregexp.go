@@ -0,0 +1,113 @@
+// The sqliteregexp package registers a go-sqlite3 driver which
+// defines the regexp() function that SQLite uses for the REGEXP
+// operator.  SQLite doesn't ship with a regexp() function, so
+// filters.Regexp will fail on SQLite connections unless the driver
+// defines one.  To use it, import this package and open your
+// connection using DriverName:
+//
+//     import _ "github.com/nelsam/gorq/extensions/sqliteregexp"
+//
+//     db, err := sql.Open(sqliteregexp.DriverName, "test.db")
+//
+// Patterns use Go's regular expression syntax.
+package sqliteregexp
+
+import (
+	"container/list"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// DriverName is the name that the regexp-enabled driver is
+// registered under.
+const DriverName = "sqlite3_regexp"
+
+// maxPatterns is the number of compiled patterns to keep cached.
+const maxPatterns = 128
+
+// cachedPattern is an entry in the pattern cache.
+type cachedPattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+var (
+	patternLock sync.Mutex
+	patterns    = make(map[string]*list.Element)
+
+	// patternOrder holds the cached patterns, from most to least
+	// recently used.
+	patternOrder = list.New()
+)
+
+func init() {
+	sql.Register(DriverName, &sqlite3.SQLiteDriver{ConnectHook: ConnectHook})
+}
+
+// ConnectHook registers the regexp() function on conn.  If you need
+// your own ConnectHook, you can call this from it.
+func ConnectHook(conn *sqlite3.SQLiteConn) error {
+	return conn.RegisterFunc("regexp", Regexp, true)
+}
+
+// compile returns the compiled version of pattern, keeping the most
+// recently used patterns cached.
+func compile(pattern string) (*regexp.Regexp, error) {
+	patternLock.Lock()
+	defer patternLock.Unlock()
+	if elem, ok := patterns[pattern]; ok {
+		patternOrder.MoveToFront(elem)
+		return elem.Value.(cachedPattern).re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns[pattern] = patternOrder.PushFront(cachedPattern{pattern: pattern, re: re})
+	if patternOrder.Len() > maxPatterns {
+		oldest := patternOrder.Remove(patternOrder.Back()).(cachedPattern)
+		delete(patterns, oldest.pattern)
+	}
+	return re, nil
+}
+
+// isNull reports whether value is an SQLite NULL, which go-sqlite3
+// passes to functions as a nil []byte.
+func isNull(value interface{}) bool {
+	if b, ok := value.([]byte); ok {
+		return b == nil
+	}
+	return value == nil
+}
+
+// text converts an SQLite value to the text that REGEXP should match
+// against.
+func text(value interface{}) string {
+	switch src := value.(type) {
+	case string:
+		return src
+	case []byte:
+		return string(src)
+	}
+	return fmt.Sprint(value)
+}
+
+// Regexp reports whether value matches pattern.  It is registered as
+// SQLite's regexp() function, so its arguments are in the order that
+// SQLite passes them for "value REGEXP pattern".  Like other SQL
+// comparisons, the result is NULL if either argument is NULL.
+// Numeric values are matched against their text representation.
+func Regexp(pattern, value interface{}) (interface{}, error) {
+	if isNull(pattern) || isNull(value) {
+		return nil, nil
+	}
+	re, err := compile(text(pattern))
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(text(value)), nil
+}
@@ -0,0 +1,68 @@
+package sqliteregexp_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/nelsam/gorq/extensions/sqliteregexp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexp(t *testing.T) {
+	db, err := sql.Open(sqliteregexp.DriverName, ":memory:")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer db.Close()
+
+	for pattern, expected := range map[string]bool{
+		"^te.t$":  true,
+		"^TEST$":  false,
+		"(?i)^TE": true,
+		"x":       false,
+	} {
+		var matched bool
+		err := db.QueryRow("SELECT 'test' REGEXP ?", pattern).Scan(&matched)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, matched, "Pattern %s", pattern)
+		}
+	}
+}
+
+func TestRegexpNull(t *testing.T) {
+	db, err := sql.Open(sqliteregexp.DriverName, ":memory:")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer db.Close()
+
+	var matched sql.NullBool
+	err = db.QueryRow("SELECT NULL REGEXP ?", "^te").Scan(&matched)
+	if assert.NoError(t, err) {
+		assert.False(t, matched.Valid)
+	}
+	var count int64
+	err = db.QueryRow("SELECT COUNT(*) FROM (SELECT 'test' AS v UNION ALL SELECT NULL UNION ALL SELECT 42) WHERE v REGEXP ?", "^(te|4)").Scan(&count)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), count)
+	}
+}
+
+func TestRegexpCache(t *testing.T) {
+	db, err := sql.Open(sqliteregexp.DriverName, ":memory:")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer db.Close()
+
+	// More patterns than the cache holds should still match
+	// correctly.
+	for i := 0; i < 300; i++ {
+		var matched bool
+		err := db.QueryRow("SELECT ? REGEXP ?", fmt.Sprintf("value %d", i), fmt.Sprintf("^value %d$", i)).Scan(&matched)
+		if assert.NoError(t, err) {
+			assert.True(t, matched)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package filters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+)
+
+// dateTrunc is a DialectWrapper that truncates a date.
+type dateTrunc struct {
+	unit  string
+	value interface{}
+}
+
+// mysqlTruncFormats are the DATE_FORMAT formats used to truncate
+// dates in MySQL.  Note that %s is avoided (in favor of %S), since it
+// is used as the bind variable placeholder.
+var mysqlTruncFormats = map[string]string{
+	"year":   "%Y-01-01 00:00:00",
+	"month":  "%Y-%m-01 00:00:00",
+	"day":    "%Y-%m-%d 00:00:00",
+	"hour":   "%Y-%m-%d %H:00:00",
+	"minute": "%Y-%m-%d %H:%i:00",
+	"second": "%Y-%m-%d %H:%i:%S",
+}
+
+// sqliteTruncFormats are the strftime formats used to truncate dates
+// in SQLite.
+var sqliteTruncFormats = map[string]string{
+	"year":   "%Y-01-01 00:00:00",
+	"month":  "%Y-%m-01 00:00:00",
+	"week":   "%Y-%m-%d 00:00:00",
+	"day":    "%Y-%m-%d 00:00:00",
+	"hour":   "%Y-%m-%d %H:00:00",
+	"minute": "%Y-%m-%d %H:%M:00",
+	"second": "%Y-%m-%d %H:%M:%S",
+}
+
+func (d dateTrunc) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	if _, ok := sqliteTruncFormats[d.unit]; !ok {
+		return nil, fmt.Errorf("gorp: Dates cannot be truncated to the unit %q", d.unit)
+	}
+	switch dialect.(type) {
+	case dialects.MySQLDialect:
+		if d.unit == "week" {
+			return FormatWrapper{
+				Values: []interface{}{d.value, d.value},
+				Format: func(sqlValues ...string) string {
+					return "CAST(DATE_SUB(DATE(" + sqlValues[0] + "), INTERVAL WEEKDAY(" + sqlValues[1] + ") DAY) AS DATETIME)"
+				},
+			}, nil
+		}
+		return FormatWrapper{
+			Values: []interface{}{d.value},
+			Format: func(sqlValues ...string) string {
+				return "CAST(DATE_FORMAT(" + sqlValues[0] + ", '" + mysqlTruncFormats[d.unit] + "') AS DATETIME)"
+			},
+		}, nil
+	case dialects.SqliteDialect:
+		modifiers := ""
+		if d.unit == "week" {
+			// Move forward to Sunday, then back to Monday.
+			modifiers = ", 'weekday 0', '-6 days'"
+		}
+		return FormatWrapper{
+			Values: []interface{}{d.value},
+			Format: func(sqlValues ...string) string {
+				return "strftime('" + sqliteTruncFormats[d.unit] + "', " + sqlValues[0] + modifiers + ")"
+			},
+		}, nil
+	default:
+		return FormatWrapper{
+			Values: []interface{}{d.value},
+			Format: func(sqlValues ...string) string {
+				return "date_trunc('" + d.unit + "', " + sqlValues[0] + ")"
+			},
+		}, nil
+	}
+}
+
+// DateTrunc returns a DialectWrapper for value truncated to the start
+// of unit, which must be one of "year", "month", "week", "day",
+// "hour", "minute", or "second".  Weeks start on Monday.  Most code
+// should use gorq.DateTrunc, which is the same function; it is
+// defined here so that query plans can use it.
+func DateTrunc(unit string, value interface{}) DialectWrapper {
+	return dateTrunc{unit: strings.ToLower(unit), value: value}
+}
@@ -0,0 +1,85 @@
+package filters
+
+import (
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+)
+
+// An ExpressionFilter is a Filter that is made up of a single
+// boolean SQL expression, such as a MultiSqlWrapper or a
+// DialectWrapper that generates a boolean value.
+type ExpressionFilter struct {
+	Expression interface{}
+}
+
+func (filter *ExpressionFilter) ActualValues() []interface{} {
+	return []interface{}{filter.Expression}
+}
+
+func (filter *ExpressionFilter) Where(values ...string) string {
+	return values[0]
+}
+
+// Expression returns a filter that uses expression as its condition.
+// This is mainly useful for SqlWrapper, MultiSqlWrapper, and
+// DialectWrapper values that generate boolean SQL.
+func Expression(expression interface{}) Filter {
+	return &ExpressionFilter{Expression: expression}
+}
+
+// A DistinctFilter is a null-safe comparison between two values.
+// Unlike an equality check, comparing against NULL using a
+// DistinctFilter will never result in NULL - NULL is not distinct
+// from NULL, but it is distinct from every other value.
+type DistinctFilter struct {
+	Left  interface{}
+	Right interface{}
+
+	// Distinct should be true for IS DISTINCT FROM and false for IS
+	// NOT DISTINCT FROM.
+	Distinct bool
+}
+
+// ForDialect implements DialectWrapper.ForDialect.
+func (filter *DistinctFilter) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	comparison := &ComparisonFilter{Left: filter.Left, Right: filter.Right}
+	switch dialect.(type) {
+	case dialects.MySQLDialect:
+		comparison.Comparison = " <=> "
+		if filter.Distinct {
+			return Not(comparison), nil
+		}
+	case dialects.SqliteDialect:
+		comparison.Comparison = " IS "
+		if filter.Distinct {
+			comparison.Comparison = " IS NOT "
+		}
+	default:
+		comparison.Comparison = " IS NOT DISTINCT FROM "
+		if filter.Distinct {
+			comparison.Comparison = " IS DISTINCT FROM "
+		}
+	}
+	return comparison, nil
+}
+
+// IsDistinctFrom returns a filter for fieldPtr IS DISTINCT FROM
+// value, using the equivalent null-safe comparison for dialects
+// that don't support IS DISTINCT FROM.
+func IsDistinctFrom(fieldPtr interface{}, value interface{}) Filter {
+	return Expression(&DistinctFilter{
+		Left:     fieldPtr,
+		Right:    value,
+		Distinct: true,
+	})
+}
+
+// IsNotDistinctFrom returns a filter for fieldPtr IS NOT DISTINCT
+// FROM value, using the equivalent null-safe comparison for dialects
+// that don't support IS NOT DISTINCT FROM.
+func IsNotDistinctFrom(fieldPtr interface{}, value interface{}) Filter {
+	return Expression(&DistinctFilter{
+		Left:  fieldPtr,
+		Right: value,
+	})
+}
@@ -1,7 +1,6 @@
 package filters
 
 import (
-	"bytes"
 	"fmt"
 	"strings"
 )
@@ -47,23 +46,22 @@ func (filter *CombinedFilter) ActualValues() []interface{} {
 }
 
 func (filter *CombinedFilter) where(separator string, values ...string) string {
-	buf := bytes.Buffer{}
-	if len(filter.subFilters) > 1 {
-		buf.WriteString("(")
-	}
+	wheres := make([]string, 0, len(filter.subFilters))
 	index := 0
 	for _, subFilter := range filter.subFilters {
-		if index != 0 {
-			buf.WriteString(separator)
-		}
 		end := index + len(subFilter.ActualValues())
-		buf.WriteString(subFilter.Where(values[index:end]...))
+		where := subFilter.Where(values[index:end]...)
 		index = end
+		// Empty filters (e.g. an AndFilter with no sub-filters) have
+		// no effect on the results.
+		if where != "" {
+			wheres = append(wheres, where)
+		}
 	}
-	if len(filter.subFilters) > 1 {
-		buf.WriteString(")")
+	if len(wheres) > 1 {
+		return "(" + strings.Join(wheres, separator) + ")"
 	}
-	return buf.String()
+	return strings.Join(wheres, separator)
 }
 
 // Add adds one or more filters to the slice of sub-filters.
@@ -294,8 +292,13 @@ func Like(fieldPtr interface{}, pattern string) Filter {
 	}
 }
 
-// Equal returns a filter for fieldPtr == value
+// Equal returns a filter for fieldPtr == value.  If value is nil,
+// the filter will be for fieldPtr IS NULL instead, since comparing
+// against NULL using = will never match.
 func Equal(fieldPtr interface{}, value interface{}) Filter {
+	if value == nil {
+		return Null(fieldPtr)
+	}
 	return &ComparisonFilter{
 		Left:       fieldPtr,
 		Comparison: "=",
@@ -303,8 +306,12 @@ func Equal(fieldPtr interface{}, value interface{}) Filter {
 	}
 }
 
-// NotEqual returns a filter for fieldPtr != value
+// NotEqual returns a filter for fieldPtr != value.  If value is nil,
+// the filter will be for fieldPtr IS NOT NULL instead.
 func NotEqual(fieldPtr interface{}, value interface{}) Filter {
+	if value == nil {
+		return NotNull(fieldPtr)
+	}
 	return &ComparisonFilter{
 		Left:       fieldPtr,
 		Comparison: "<>",
@@ -1 +1,32 @@
 package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, "foo", EscapeLike("foo"))
+	assert.Equal(t, `100\% \_off\\`, EscapeLike(`100% _off\`))
+}
+
+func TestRaw(t *testing.T) {
+	filter := Raw("? @@ to_tsquery(?) AND data ?? ?", "a", "b", "c")
+	assert.Equal(t, []interface{}{"a", "b", "c"}, filter.ActualValues())
+	assert.Equal(t, "x @@ to_tsquery(y) AND data ? z", filter.Where("x", "y", "z"))
+
+	filter = Raw("? = ?", "a")
+	values := filter.ActualValues()
+	if assert.Equal(t, 1, len(values)) {
+		_, err := values[0].(DialectWrapper).ForDialect(nil)
+		assert.Error(t, err)
+	}
+
+	filter = Raw("DATE_FORMAT(?, '%Y-%m-%d %H:%i:%s')", "a")
+	values = filter.ActualValues()
+	if assert.Equal(t, 1, len(values)) {
+		_, err := values[0].(DialectWrapper).ForDialect(nil)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,37 @@
+package filters
+
+import "strings"
+
+// A FormatWrapper is a MultiSqlWrapper that generates its SQL by
+// passing the SQL for each of Values to Format.  Most expressions
+// that don't need special handling can be built with it:
+//
+//     filters.FormatWrapper{
+//         Values: []interface{}{&ref.Price, &ref.Quantity},
+//         Format: func(sqlValues ...string) string {
+//             return "(" + sqlValues[0] + " * " + sqlValues[1] + ")"
+//         },
+//     }
+type FormatWrapper struct {
+	Values []interface{}
+	Format func(sqlValues ...string) string
+}
+
+func (wrapper FormatWrapper) ActualValues() []interface{} {
+	return wrapper.Values
+}
+
+func (wrapper FormatWrapper) WrapSql(sqlValues ...string) string {
+	return wrapper.Format(sqlValues...)
+}
+
+// Function returns a FormatWrapper for a call to the SQL function
+// name with args.
+func Function(name string, args ...interface{}) FormatWrapper {
+	return FormatWrapper{
+		Values: args,
+		Format: func(sqlValues ...string) string {
+			return name + "(" + strings.Join(sqlValues, ", ") + ")"
+		},
+	}
+}
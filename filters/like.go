@@ -0,0 +1,126 @@
+package filters
+
+import (
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+)
+
+// LikeEscapeChar is the character used to escape wildcards in
+// patterns generated by Contains, StartsWith, and EndsWith.
+const LikeEscapeChar = `\`
+
+var likeEscaper = strings.NewReplacer(
+	LikeEscapeChar, LikeEscapeChar+LikeEscapeChar,
+	"%", LikeEscapeChar+"%",
+	"_", LikeEscapeChar+"_",
+)
+
+// EscapeLike escapes all LIKE wildcards in value, so that it can be
+// used in a LIKE pattern as a literal string.  The resulting pattern
+// must be used with an ESCAPE clause for LikeEscapeChar, which a
+// LikeFilter will add.
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// A LikeFilter is a filter for a LIKE comparison with an ESCAPE
+// clause.  Any wildcards in Pattern which should be matched
+// literally must already be escaped (see EscapeLike).
+type LikeFilter struct {
+	Left    interface{}
+	Pattern string
+
+	// CaseInsensitive should be true to ignore case when matching
+	// Pattern.
+	CaseInsensitive bool
+}
+
+// ForDialect implements DialectWrapper.ForDialect.
+func (filter *LikeFilter) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	like := &escapedLikeFilter{
+		left:       filter.Left,
+		comparison: " like ",
+		pattern:    filter.Pattern,
+		escape:     "'" + LikeEscapeChar + "'",
+	}
+	switch dialect.(type) {
+	case dialects.MySQLDialect:
+		// MySQL treats backslashes in string literals as escape
+		// characters.
+		like.escape = "'" + LikeEscapeChar + LikeEscapeChar + "'"
+	case dialects.SqliteDialect:
+	default:
+		if filter.CaseInsensitive {
+			like.comparison = " ilike "
+			return like, nil
+		}
+	}
+	if filter.CaseInsensitive {
+		like.left = Function("lower", like.left)
+		like.pattern = Function("lower", like.pattern)
+	}
+	return like, nil
+}
+
+// escapedLikeFilter is the dialect-specific filter generated by a
+// LikeFilter.
+type escapedLikeFilter struct {
+	left       interface{}
+	comparison string
+	pattern    interface{}
+	escape     string
+}
+
+func (filter *escapedLikeFilter) ActualValues() []interface{} {
+	return []interface{}{filter.left, filter.pattern}
+}
+
+func (filter *escapedLikeFilter) Where(values ...string) string {
+	return values[0] + filter.comparison + values[1] + " ESCAPE " + filter.escape
+}
+
+func escapedLike(fieldPtr interface{}, pattern string, caseInsensitive bool) Filter {
+	return Expression(&LikeFilter{
+		Left:            fieldPtr,
+		Pattern:         pattern,
+		CaseInsensitive: caseInsensitive,
+	})
+}
+
+// Contains returns a filter for fieldPtr containing value.  Any LIKE
+// wildcards in value are escaped, so they will be matched literally.
+func Contains(fieldPtr interface{}, value string) Filter {
+	return escapedLike(fieldPtr, "%"+EscapeLike(value)+"%", false)
+}
+
+// StartsWith returns a filter for fieldPtr starting with value.  Any
+// LIKE wildcards in value are escaped, so they will be matched
+// literally.
+func StartsWith(fieldPtr interface{}, value string) Filter {
+	return escapedLike(fieldPtr, EscapeLike(value)+"%", false)
+}
+
+// EndsWith returns a filter for fieldPtr ending with value.  Any LIKE
+// wildcards in value are escaped, so they will be matched literally.
+func EndsWith(fieldPtr interface{}, value string) Filter {
+	return escapedLike(fieldPtr, "%"+EscapeLike(value), false)
+}
+
+// IContains is the case-insensitive version of Contains.  It uses
+// ILIKE on postgres (the same as extensions.ILike), and compares
+// lower() values everywhere else.
+func IContains(fieldPtr interface{}, value string) Filter {
+	return escapedLike(fieldPtr, "%"+EscapeLike(value)+"%", true)
+}
+
+// IStartsWith is the case-insensitive version of StartsWith.
+func IStartsWith(fieldPtr interface{}, value string) Filter {
+	return escapedLike(fieldPtr, EscapeLike(value)+"%", true)
+}
+
+// IEndsWith is the case-insensitive version of EndsWith.
+func IEndsWith(fieldPtr interface{}, value string) Filter {
+	return escapedLike(fieldPtr, "%"+EscapeLike(value), true)
+}
@@ -0,0 +1,95 @@
+package filters
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+)
+
+// invalidValue is a value that can't be used in a query, causing the
+// query to return err when it is generated.
+type invalidValue struct {
+	err error
+}
+
+func (value invalidValue) ForDialect(gorp.Dialect) (interface{}, error) {
+	return nil, value.err
+}
+
+// A RawFilter is a fragment of SQL with a "?" placeholder for each of
+// its values.  It is both a Filter and a MultiSqlWrapper, so it can be
+// used as a condition or as a value.
+type RawFilter struct {
+	Sql    string
+	Values []interface{}
+}
+
+// placeholders returns the number of placeholders in filter.Sql.
+func (filter *RawFilter) placeholders() int {
+	return strings.Count(filter.Sql, "?") - 2*strings.Count(filter.Sql, "??")
+}
+
+func (filter *RawFilter) ActualValues() []interface{} {
+	// The query is generated with "%s" as the placeholder for bind
+	// variables (see plans.BindVarPlaceholder), so any "%s" in the
+	// raw SQL would be replaced with a bind variable.
+	if strings.Contains(filter.Sql, "%s") {
+		err := fmt.Errorf(`gorp: Raw SQL %q contains "%%s", which is reserved; pass it as a value instead`, filter.Sql)
+		return []interface{}{invalidValue{err}}
+	}
+	if count := filter.placeholders(); count != len(filter.Values) {
+		err := fmt.Errorf("gorp: Raw SQL %q has %d placeholders, but %d values", filter.Sql, count, len(filter.Values))
+		return []interface{}{invalidValue{err}}
+	}
+	return filter.Values
+}
+
+func (filter *RawFilter) Where(values ...string) string {
+	buf := new(bytes.Buffer)
+	sql := filter.Sql
+	for {
+		index := strings.IndexByte(sql, '?')
+		if index < 0 {
+			buf.WriteString(sql)
+			return buf.String()
+		}
+		buf.WriteString(sql[:index])
+		sql = sql[index+1:]
+		if strings.HasPrefix(sql, "?") {
+			buf.WriteString("?")
+			sql = sql[1:]
+			continue
+		}
+		buf.WriteString(values[0])
+		values = values[1:]
+	}
+}
+
+func (filter *RawFilter) WrapSql(values ...string) string {
+	return filter.Where(values...)
+}
+
+// Raw returns a filter for a fragment of SQL that the other filters
+// can't express.  Each "?" in sql is replaced with the matching value
+// in values, which are handled the same way as values in any other
+// filter: field pointers are replaced with their (quoted) column
+// names, wrappers are replaced with their SQL, and anything else is
+// passed to the query as a bind argument.  For example:
+//
+//     query.Filter(filters.Raw("? @@ plainto_tsquery(?)", &ref.SearchVector, term))
+//
+// Use "??" for a literal "?" (e.g. for PostgreSQL's JSON operators).
+// Only use it with PostgreSQL: MySQL and SQLite use "?" for their own
+// bind variables, so they would read the literal "?" as a bind
+// variable with no argument.
+//
+// The sql should not contain any literal values; pass them as values,
+// instead.  In particular, it may not contain "%s" (e.g. in a MySQL
+// DATE_FORMAT string), which is reserved for generating queries.  If
+// sql contains "%s", or if the number of placeholders doesn't match
+// the number of values, the query will return an error.
+func Raw(sql string, values ...interface{}) Filter {
+	return &RawFilter{Sql: sql, Values: values}
+}
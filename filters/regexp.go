@@ -0,0 +1,84 @@
+package filters
+
+import (
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+)
+
+// A RegexpFilter is a filter that matches a value against a regular
+// expression.
+type RegexpFilter struct {
+	Left    interface{}
+	Pattern string
+
+	// Not should be true to match values that do *not* match
+	// Pattern.
+	Not bool
+
+	// CaseInsensitive should be true to ignore case when matching
+	// Pattern.
+	CaseInsensitive bool
+}
+
+// ForDialect implements DialectWrapper.ForDialect.
+func (filter *RegexpFilter) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	var match Filter
+	switch dialect.(type) {
+	case dialects.MySQLDialect:
+		// A plain REGEXP follows the collation of its arguments,
+		// which is case-insensitive by default, so the match type is
+		// always given explicitly.  REGEXP_LIKE requires MySQL 8.0 or
+		// newer.
+		matchType := "c"
+		if filter.CaseInsensitive {
+			matchType = "i"
+		}
+		match = Expression(Function("REGEXP_LIKE", filter.Left, filter.Pattern, matchType))
+	case dialects.SqliteDialect:
+		// SQLite doesn't define a regexp() function by default; see
+		// the sqliteregexp package for a driver that uses Go's
+		// regexp package, which understands the (?i) flag.
+		pattern := filter.Pattern
+		if filter.CaseInsensitive {
+			pattern = "(?i)" + pattern
+		}
+		match = &ComparisonFilter{Left: filter.Left, Comparison: " REGEXP ", Right: pattern}
+	default:
+		comparison := " ~"
+		if filter.Not {
+			comparison = " !~"
+		}
+		if filter.CaseInsensitive {
+			comparison += "*"
+		}
+		return &ComparisonFilter{Left: filter.Left, Comparison: comparison + " ", Right: filter.Pattern}, nil
+	}
+	if filter.Not {
+		return Not(match), nil
+	}
+	return match, nil
+}
+
+// Regexp returns a filter for fieldPtr matching the regular
+// expression pattern.  On SQLite, a regexp() function must be
+// registered with the connection - see the sqliteregexp package.  On
+// MySQL, it uses REGEXP_LIKE, which requires MySQL 8.0 or newer.
+func Regexp(fieldPtr interface{}, pattern string) Filter {
+	return Expression(&RegexpFilter{Left: fieldPtr, Pattern: pattern})
+}
+
+// NotRegexp returns a filter for fieldPtr not matching the regular
+// expression pattern.
+func NotRegexp(fieldPtr interface{}, pattern string) Filter {
+	return Expression(&RegexpFilter{Left: fieldPtr, Pattern: pattern, Not: true})
+}
+
+// IRegexp is the case-insensitive version of Regexp.
+func IRegexp(fieldPtr interface{}, pattern string) Filter {
+	return Expression(&RegexpFilter{Left: fieldPtr, Pattern: pattern, CaseInsensitive: true})
+}
+
+// NotIRegexp is the case-insensitive version of NotRegexp.
+func NotIRegexp(fieldPtr interface{}, pattern string) Filter {
+	return Expression(&RegexpFilter{Left: fieldPtr, Pattern: pattern, Not: true, CaseInsensitive: true})
+}
@@ -0,0 +1,72 @@
+package filters
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+)
+
+// A FullTextSearcher generates filters using some form of full-text
+// search, for use with SearchWith.
+type FullTextSearcher interface {
+	// FullTextSearch should return a filter that matches rows where
+	// any of the columns for fieldPtrs match term.
+	FullTextSearch(term string, fieldPtrs ...interface{}) Filter
+}
+
+// A SearchFilter is a filter for a user-entered search term, matched
+// against one or more fields.
+type SearchFilter struct {
+	Term   string
+	Fields []interface{}
+
+	// Searcher, if set, is used to generate the filter instead of
+	// LIKE comparisons.
+	Searcher FullTextSearcher
+}
+
+// ForDialect implements DialectWrapper.ForDialect.  A Term with no
+// words matches every row.  Otherwise, unless Searcher is set, Term
+// will be split into words, and every word must be contained
+// (case-insensitively) in at least one of Fields for a row to match.
+func (filter *SearchFilter) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	if len(filter.Fields) == 0 {
+		return nil, errors.New("gorp: Search requires at least one field to search")
+	}
+	words := strings.Fields(filter.Term)
+	if len(words) == 0 {
+		// An empty filter would generate invalid SQL when it is
+		// negated, so this needs to be an actual expression.
+		return Raw("1=1"), nil
+	}
+	if filter.Searcher != nil {
+		return filter.Searcher.FullTextSearch(filter.Term, filter.Fields...), nil
+	}
+	wordFilters := make([]Filter, 0, len(words))
+	for _, word := range words {
+		fieldFilters := make([]Filter, 0, len(filter.Fields))
+		for _, field := range filter.Fields {
+			fieldFilters = append(fieldFilters, IContains(field, word))
+		}
+		wordFilters = append(wordFilters, Or(fieldFilters...))
+	}
+	return And(wordFilters...), nil
+}
+
+// Search returns a filter for rows matching term in any of
+// fieldPtrs, using LIKE comparisons.  A term with no words matches
+// every row, but the query will return an error if there are no
+// fieldPtrs.
+func Search(term string, fieldPtrs ...interface{}) Filter {
+	return Expression(&SearchFilter{Term: term, Fields: fieldPtrs})
+}
+
+// SearchWith is like Search, except that searcher is used to generate
+// the filter instead of LIKE comparisons:
+//
+//     english := extensions.PostgresFullText{Config: "english"}
+//     query.Where(filters.SearchWith(english, term, &ref.Title, &ref.Body))
+func SearchWith(searcher FullTextSearcher, term string, fieldPtrs ...interface{}) Filter {
+	return Expression(&SearchFilter{Term: term, Fields: fieldPtrs, Searcher: searcher})
+}
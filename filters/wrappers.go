@@ -1,5 +1,7 @@
 package filters
 
+import "github.com/go-gorp/gorp"
+
 type SqlWrapper interface {
 	// ActualValue should return the value to be used as a value or
 	// column in the SQL query.
@@ -23,3 +25,43 @@ type MultiSqlWrapper interface {
 	// whatever SQL this SqlWrapper needs to add to the query.
 	WrapSql(...string) string
 }
+
+// A DialectWrapper is a value that can only decide what SQL it
+// should generate once the dialect of the query is known.  It can be
+// used anywhere that a SqlWrapper or MultiSqlWrapper can be used.
+type DialectWrapper interface {
+	// ForDialect should return the value to use in place of this
+	// DialectWrapper when generating SQL for dialect.  The returned
+	// value is treated like any other value in the query - it may be
+	// a field pointer, a SqlWrapper, a MultiSqlWrapper, a Filter, or
+	// a value to bind as an argument.  An error should be returned
+	// if the dialect is not supported.
+	ForDialect(dialect gorp.Dialect) (interface{}, error)
+}
+
+// A BoundValue is a value that will always be passed to the query as
+// a bind argument, even if it is a pointer to a field that is in use
+// as a reference for the query.
+type BoundValue struct {
+	Value interface{}
+}
+
+// Value returns a BoundValue for value, forcing it to be used as a
+// bind argument.
+func Value(value interface{}) BoundValue {
+	return BoundValue{Value: value}
+}
+
+// A ColumnRef is a pointer to a field which must be used as a
+// column.  If the field can't be found in any of the reference
+// structs for the query, the query will return an error rather than
+// using the pointer as a bind argument.
+type ColumnRef struct {
+	FieldPtr interface{}
+}
+
+// Col returns a ColumnRef for fieldPtr, forcing it to be used as a
+// column.
+func Col(fieldPtr interface{}) ColumnRef {
+	return ColumnRef{FieldPtr: fieldPtr}
+}
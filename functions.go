@@ -0,0 +1,190 @@
+package gorq
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/filters"
+)
+
+var (
+	funcNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+	// castTypePattern matches a type name, which is either a single
+	// identifier or several words (checked against multiWordTypes),
+	// followed by optional size arguments and array brackets.
+	castTypePattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*(?: [A-Za-z_][A-Za-z0-9_]*)*) ?(\(\d+(?: ?, ?\d+)?\))?(\[\])?$`)
+)
+
+// multiWordTypes are the type names made up of more than one word
+// that Cast accepts.
+var multiWordTypes = map[string]bool{
+	"double precision":            true,
+	"character varying":           true,
+	"bit varying":                 true,
+	"time with time zone":         true,
+	"time without time zone":      true,
+	"timestamp with time zone":    true,
+	"timestamp without time zone": true,
+	"signed integer":              true,
+	"unsigned integer":            true,
+}
+
+// castKeywords are SQL keywords that are not type names, but could
+// change the meaning of a query if they were used as one.
+var castKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "is": true, "in": true,
+	"like": true, "between": true, "select": true, "from": true,
+	"where": true, "union": true, "as": true, "null": true,
+	"true": true, "false": true, "case": true, "when": true,
+	"then": true, "else": true, "end": true,
+}
+
+// validCastType returns an error if sqlType isn't a type name that
+// Cast accepts.
+func validCastType(sqlType string) error {
+	match := castTypePattern.FindStringSubmatch(sqlType)
+	if match == nil {
+		return fmt.Errorf("gorp: %q is not a valid type to cast to", sqlType)
+	}
+	name := strings.ToLower(match[1])
+	if strings.Contains(name, " ") {
+		if !multiWordTypes[name] {
+			return fmt.Errorf("gorp: %q is not a known type to cast to", sqlType)
+		}
+		return nil
+	}
+	if castKeywords[name] {
+		return fmt.Errorf("gorp: %q is a keyword, not a type to cast to", sqlType)
+	}
+	return nil
+}
+
+// A FuncValidator validates function names before they are used in a
+// query, returning an error for any names that aren't allowed.  Its
+// Func method generates function calls that are checked by it.
+type FuncValidator func(name string) error
+
+// ValidFuncName returns an error if name is not a valid function
+// name: an identifier made up of letters, digits, and underscores,
+// optionally qualified by a schema name.
+func ValidFuncName(name string) error {
+	if !funcNamePattern.MatchString(name) {
+		return fmt.Errorf("gorp: %q is not a valid function name", name)
+	}
+	return nil
+}
+
+// FuncWhitelist returns a FuncValidator that only allows the passed
+// in names (case-insensitive).  Example:
+//
+//     allowed := gorq.FuncWhitelist("date_part", "similarity")
+//     results, err := dbMap.Query(ref).
+//         Where().
+//         Greater(allowed.Func("similarity", &ref.Name, name), 0.3).
+//         Select()
+func FuncWhitelist(names ...string) FuncValidator {
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		allowed[strings.ToLower(name)] = true
+	}
+	return func(name string) error {
+		if err := ValidFuncName(name); err != nil {
+			return err
+		}
+		if !allowed[strings.ToLower(name)] {
+			return fmt.Errorf("gorp: Function %q is not in the whitelist", name)
+		}
+		return nil
+	}
+}
+
+// funcCall is a call to an arbitrary SQL function.  The function name
+// is validated when the query is generated.
+type funcCall struct {
+	filters.FormatWrapper
+	name     string
+	validate FuncValidator
+}
+
+func (f funcCall) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	if err := f.validate(f.name); err != nil {
+		return nil, err
+	}
+	return f.FormatWrapper, nil
+}
+
+// Func is like the package-level Func, except that name is checked
+// using validate.
+func (validate FuncValidator) Func(name string, args ...interface{}) filters.MultiSqlWrapper {
+	return funcCall{
+		FormatWrapper: filters.Function(name, args...),
+		name:          name,
+		validate:      validate,
+	}
+}
+
+// Func returns a filters.MultiSqlWrapper for a call to the SQL
+// function name with args, for functions that don't have their own
+// wrapper.  Like other wrappers, args may be field pointers, other
+// wrappers, or values to bind as arguments.  Example:
+//
+//     results, err := dbMap.Query(ref).
+//         Where().
+//         Greater(gorq.Func("similarity", &ref.Name, name), 0.3).
+//         Select()
+//
+// Func only checks that name is a valid function name (see
+// ValidFuncName); it allows calls to any function, including ones
+// like pg_sleep or pg_read_file, so name should never come from user
+// input.  To restrict the functions that can be called, use the Func
+// method of a FuncValidator, e.g. one returned by FuncWhitelist.  The
+// query will return an error if name isn't allowed.
+func Func(name string, args ...interface{}) filters.MultiSqlWrapper {
+	return FuncValidator(ValidFuncName).Func(name, args...)
+}
+
+// castExpression converts a value to an SQL type.  The type is
+// validated when the query is generated.
+type castExpression struct {
+	value   interface{}
+	sqlType string
+}
+
+func (c castExpression) ForDialect(dialect gorp.Dialect) (interface{}, error) {
+	if err := validCastType(c.sqlType); err != nil {
+		return nil, err
+	}
+	return filters.FormatWrapper{
+		Values: []interface{}{c.value},
+		Format: func(sqlValues ...string) string {
+			return "CAST(" + sqlValues[0] + " AS " + c.sqlType + ")"
+		},
+	}, nil
+}
+
+// Cast returns a filters.DialectWrapper for CAST(value AS sqlType),
+// e.g. with a sqlType of "integer", "varchar(255)", or
+// "numeric(10, 2)".  Note that MySQL only supports casting to a
+// handful of types (e.g. SIGNED, CHAR, DECIMAL).  The sqlType must be
+// a single identifier or a known multi-word type (like
+// "double precision" or "timestamp with time zone"), optionally
+// followed by size arguments and "[]"; the query will return an error
+// for anything else, including SQL keywords like OR.
+func Cast(value interface{}, sqlType string) filters.DialectWrapper {
+	return castExpression{value: value, sqlType: sqlType}
+}
+
+// Raw returns a filters.MultiSqlWrapper for a fragment of SQL that
+// the other wrappers can't express, for use as a value (e.g. in
+// SelectAs, OrderBy, or Assign).  Each "?" in sql is replaced with the
+// matching value in values; see filters.Raw for details.  Example:
+//
+//     results, err := dbMap.Query(ref).
+//         OrderBy(gorq.Raw("? <-> ?", &ref.Location, point), gorq.Asc).
+//         Select()
+func Raw(sql string, values ...interface{}) filters.MultiSqlWrapper {
+	return &filters.RawFilter{Sql: sql, Values: values}
+}
@@ -1,9 +1,33 @@
 package interfaces
 
 import (
+	"time"
+
 	"github.com/nelsam/gorq/filters"
 )
 
+// Asc and Desc are the directions of an order by clause.  Directions
+// passed to OrderBy must be "ASC" or "DESC" (case-insensitive, or
+// empty for the default direction), optionally followed by
+// "NULLS FIRST" or "NULLS LAST"; any other value will result in an
+// error when the query is built.
+const (
+	Asc  = "ASC"
+	Desc = "DESC"
+)
+
+// NullsFirst returns direction, modified to sort null values before
+// all other values.
+func NullsFirst(direction string) string {
+	return direction + " NULLS FIRST"
+}
+
+// NullsLast returns direction, modified to sort null values after
+// all other values.
+func NullsLast(direction string) string {
+	return direction + " NULLS LAST"
+}
+
 // A NonstandardLimiter is a type of query dialect that doesn't
 // support the SQL standard method of limiting query results (i.e.
 // fetch (N) rows only).  It instead returns its own LIMIT clause.
@@ -54,6 +78,136 @@ type Selector interface {
 	// Count executes a select statement that just returns a count of
 	// the number of rows that would be returned.
 	Count() (int64, error)
+
+	// First executes the select statement with a limit of 1 and
+	// returns the resulting row.  If no rows match, the error will
+	// be sql.ErrNoRows.
+	First() (result interface{}, err error)
+
+	// One executes the select statement and returns the resulting
+	// row.  If no rows match, the error will be sql.ErrNoRows; if more
+	// than one row matches, the error will be plans.ErrMultipleRows.
+	One() (result interface{}, err error)
+
+	// SelectInto executes a select statement for the values passed
+	// to SelectAs, appending the resulting rows to target, which
+	// must be a pointer to a slice of result structs.  Any field
+	// pointers passed to SelectAs must point to fields on resultRef.
+	SelectInto(target interface{}, resultRef interface{}) error
+
+	// SelectMaps executes the select statement and returns each row
+	// as a map of column alias to value.
+	SelectMaps() ([]map[string]interface{}, error)
+
+	// CountBy executes a select statement that counts the matching
+	// rows for each value of fieldPtrOrWrapper.  The result is a map
+	// from the field's type to int64, e.g. map[string]int64.
+	CountBy(fieldPtrOrWrapper interface{}) (counts interface{}, err error)
+
+	// TimeBuckets returns a query that aggregates the matching rows
+	// in buckets of timeFieldPtr, truncated to unit (e.g. "hour" or
+	// "day").
+	TimeBuckets(timeFieldPtr interface{}, unit string) TimeBucketQuery
+
+	// Paginate executes the select statement for a single page of
+	// results (starting at page 1), returning the results along
+	// with the total number of matching rows, ignoring any limit,
+	// offset, or order by clauses.
+	Paginate(page, perPage int64) (results []interface{}, total int64, err error)
+
+	// KeysetPage executes the select statement for at most limit
+	// rows that sort after afterValues (nil, a slice of values for
+	// the key fields, or a cursor returned by a previous call),
+	// ordered by orderFields (or the OrderBy list, if orderFields is
+	// empty).  The returned cursor can be passed as afterValues to
+	// load the next page, and is empty when there are no more pages.
+	KeysetPage(orderFields []interface{}, afterValues interface{}, limit int64) (results []interface{}, cursor string, err error)
+
+	// Pluck executes a select statement for a single column (or
+	// SqlWrapper expression), appending the resulting values to
+	// target, which must be a pointer to a slice.  When a field
+	// pointer is passed in, the slice's element type must match the
+	// field's type (or be a pointer to it, or a sql.Scanner).
+	Pluck(fieldPtrOrWrapper interface{}, target interface{}) error
+
+	// Exists executes a select statement that only checks whether any
+	// rows match the query.
+	Exists() (bool, error)
+
+	// Iterate executes the select statement and returns an iterator
+	// over the resulting rows.  Unlike Select, Iterate doesn't load
+	// all of the resulting rows into memory at once, so it is better
+	// suited for large result sets.
+	Iterate() (Rows, error)
+}
+
+// Rows is an iterator over the results of a select statement.  It
+// works like "database/sql".Rows, except that each row is scanned
+// into a value of the reference struct's type.
+//
+//     rows, err := dbMap.Query(ref).Where().Equal(&ref.Type, t).Iterate()
+//     if err != nil {
+//         return err
+//     }
+//     defer rows.Close()
+//     for rows.Next() {
+//         row := new(Model)
+//         if err := rows.Scan(row); err != nil {
+//             return err
+//         }
+//         // Do something with row
+//     }
+//     return rows.Err()
+type Rows interface {
+	// Next prepares the next row for Scan, returning false if there
+	// are no more rows or an error occurred.
+	Next() bool
+
+	// Scan scans the current row into target, which must be a
+	// pointer to a value of the same type as the reference struct.
+	Scan(target interface{}) error
+
+	// Err returns any error encountered during iteration.
+	Err() error
+
+	// Close closes the iterator.  It is safe to call Close more than
+	// once, and Close will be called automatically when Next returns
+	// false.
+	Close() error
+}
+
+// A TimeBucket is the aggregated value of the rows in a single
+// bucket of a TimeBucketQuery.
+type TimeBucket struct {
+	Start time.Time
+	Value float64
+}
+
+// A TimeBucketQuery aggregates rows in buckets of time, e.g. to count
+// the matching rows for each hour.  Buckets are returned in order.
+//
+//     buckets, err := dbMap.Query(ref).
+//         Where().
+//         GreaterOrEqual(&ref.CreatedAt, start).
+//         Less(&ref.CreatedAt, end).
+//         TimeBuckets(&ref.CreatedAt, "hour").
+//         Fill(start, end).
+//         Count()
+type TimeBucketQuery interface {
+	// Fill includes empty buckets (with a value of 0) for every
+	// bucket from the one containing from up to (but not
+	// including) to.
+	Fill(from, to time.Time) TimeBucketQuery
+
+	// Count counts the rows in each bucket.
+	Count() ([]TimeBucket, error)
+
+	// Sum adds up fieldPtrOrWrapper for the rows in each bucket.
+	Sum(fieldPtrOrWrapper interface{}) ([]TimeBucket, error)
+
+	// Aggregate selects aggregate (e.g. gorq.Count(&ref.Id)) for
+	// each bucket.  Null values are returned as 0.
+	Aggregate(aggregate interface{}) ([]TimeBucket, error)
 }
 
 // A SelectManipulator is a query that will return a list of results
@@ -63,16 +217,60 @@ type Selector interface {
 // results[Offset:Offset+Limit].
 type SelectManipulator interface {
 	// OrderBy orders the resulting result list by a field of the
-	// reference struct and a direction, which can be "asc" or "desc".
+	// reference struct and a direction, e.g. "ASC", "DESC", or
+	// "DESC NULLS LAST".
 	OrderBy(fieldPtr interface{}, direction string) SelectQuery
 
 	// DiscardOrderBy discards any previous order by clause.  Useful
 	// if you want to take a select query and count the total results.
 	DiscardOrderBy() SelectQuery
 
+	// SelectAs adds an expression to the list of values that
+	// SelectInto and SelectMaps will select.  The as parameter is
+	// either a string alias or a pointer to a field of the result
+	// reference passed to SelectInto.
+	SelectAs(expr interface{}, as interface{}) SelectQuery
+
+	// SelectExpr selects an expression into a transient (db:"-")
+	// field of the reference struct, alongside the normal columns.
+	SelectExpr(expr interface{}, fieldPtr interface{}) SelectQuery
+
 	// GroupBy groups the result list by a field of the reference
-	// struct.
-	GroupBy(fieldPtr interface{}) SelectQuery
+	// struct, or by an expression (any type of SqlWrapper).
+	GroupBy(fieldPtrOrWrapper interface{}) SelectQuery
+
+	// GroupByRollup groups the result list by a ROLLUP of fields of
+	// the reference struct, adding subtotal rows.
+	GroupByRollup(fieldPtrs ...interface{}) SelectQuery
+
+	// GroupByCube groups the result list by a CUBE of fields of the
+	// reference struct, adding subtotal rows for every combination
+	// of the fields.
+	GroupByCube(fieldPtrs ...interface{}) SelectQuery
+
+	// GroupingSets groups the result list by GROUPING SETS, where
+	// each set is a slice of fields of the reference struct.
+	GroupingSets(sets ...[]interface{}) SelectQuery
+
+	// ForUpdate adds a FOR UPDATE clause, optionally limited to the
+	// tables of the passed in reference structs (FOR UPDATE OF).
+	// Row locks can only be used within a transaction.
+	ForUpdate(tableRefs ...interface{}) SelectQuery
+
+	// ForShare adds a FOR SHARE clause, optionally limited to the
+	// tables of the passed in reference structs (FOR SHARE OF).
+	// Row locks can only be used within a transaction.  On MySQL,
+	// FOR SHARE requires 8.0 or newer; without tables, SkipLocked,
+	// or NoWait, LOCK IN SHARE MODE is used instead.
+	ForShare(tableRefs ...interface{}) SelectQuery
+
+	// SkipLocked makes the locking clause skip rows that are
+	// already locked.
+	SkipLocked() SelectQuery
+
+	// NoWait makes the locking clause fail if any rows are already
+	// locked.
+	NoWait() SelectQuery
 
 	// Limit limits the result list to a maximum length.
 	Limit(int64) SelectQuery
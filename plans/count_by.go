@@ -0,0 +1,104 @@
+package plans
+
+import "reflect"
+
+// countByKey returns the key type for CountBy results, a destination
+// to scan each key into, and a function that returns the scanned key.
+// Field pointers are scanned into values of the field's type, with
+// NULL scanned as the zero value; anything else is scanned into an
+// interface{}.
+func (plan *QueryPlan) countByKey(fieldPtrOrWrapper interface{}) (keyType reflect.Type, dest interface{}, key func() (reflect.Value, error)) {
+	if _, err := plan.colMap.fieldMapForPointer(fieldPtrOrWrapper); err != nil {
+		var value interface{}
+		return reflect.TypeOf(&value).Elem(), &value, func() (reflect.Value, error) {
+			// []byte values can't be used as map keys.
+			if b, ok := value.([]byte); ok {
+				return reflect.ValueOf(string(b)), nil
+			}
+			return reflect.ValueOf(&value).Elem(), nil
+		}
+	}
+	fieldType := reflect.TypeOf(fieldPtrOrWrapper).Elem()
+	if conv := plan.dbMap.TypeConverter; conv != nil {
+		value := reflect.New(fieldType)
+		if scanner, ok := conv.FromDb(value.Interface()); ok {
+			return fieldType, scanner.Holder, func() (reflect.Value, error) {
+				if err := scanner.Bind(); err != nil {
+					return reflect.Value{}, err
+				}
+				return value.Elem(), nil
+			}
+		}
+	}
+	value := reflect.New(reflect.PtrTo(fieldType))
+	return fieldType, value.Interface(), func() (reflect.Value, error) {
+		if value.Elem().IsNil() {
+			return reflect.Zero(fieldType), nil
+		}
+		return value.Elem().Elem(), nil
+	}
+}
+
+// CountBy will run this query plan as a SELECT statement that groups
+// the matching rows by fieldPtrOrWrapper and counts each group.  The
+// result is a map from the field's type to int64 (e.g. a
+// map[string]int64 for a string field); for SqlWrappers and other
+// values that aren't field pointers, the map's key type is
+// interface{}.  Rows where the value is NULL are counted under the
+// zero value.  Any limit, offset, order by, or group by clauses on
+// the query are ignored.
+//
+//     counts, err := dbMap.Query(ref).
+//         Where().
+//         Equal(&ref.OwnerId, ownerId).
+//         CountBy(&ref.Status)
+//     statusCounts := counts.(map[string]int64)
+func (plan *QueryPlan) CountBy(fieldPtrOrWrapper interface{}) (counts interface{}, err error) {
+	if len(plan.Errors) > 0 {
+		return nil, plan.Errors[0]
+	}
+	plan = plan.withoutGrouping()
+
+	statement := new(Statement)
+	statement.query.WriteString("SELECT ")
+	args, column, err := plan.argOrColumn(fieldPtrOrWrapper)
+	if err != nil {
+		return nil, err
+	}
+	statement.query.WriteString(column)
+	statement.query.WriteString(",COUNT(*)")
+	statement.args = append(statement.args, args...)
+	if err := plan.addSelectSuffix(statement); err != nil {
+		return nil, err
+	}
+	// Grouping by position avoids binding the arguments for
+	// fieldPtrOrWrapper a second time.
+	statement.query.WriteString(" GROUP BY 1")
+
+	keyType, dest, key := plan.countByKey(fieldPtrOrWrapper)
+	countMap := reflect.MakeMap(reflect.MapOf(keyType, reflect.TypeOf(int64(0))))
+
+	bindVars := plan.bindVars(statement)
+	rows, err := plan.executor.Query(statement.Query(bindVars...), statement.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var count int64
+		if err := rows.Scan(dest, &count); err != nil {
+			return nil, err
+		}
+		keyVal, err := key()
+		if err != nil {
+			return nil, err
+		}
+		// NULL and zero values may both be counted under the zero
+		// value, so counts need to be added together.
+		if existing := countMap.MapIndex(keyVal); existing.IsValid() {
+			count += existing.Int()
+		}
+		countMap.SetMapIndex(keyVal, reflect.ValueOf(count))
+	}
+	return countMap.Interface(), rows.Err()
+}
@@ -0,0 +1,158 @@
+package plans
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/filters"
+	"github.com/nelsam/gorq/interfaces"
+)
+
+// A grouping is a ROLLUP, CUBE, or GROUPING SETS element of a group
+// by clause.
+type grouping struct {
+	kind string
+	sets [][]interface{}
+}
+
+// checkGroupBy returns an error if fieldPtrOrWrapper can't be used in
+// a group by clause.
+func (plan *QueryPlan) checkGroupBy(fieldPtrOrWrapper interface{}) error {
+	switch fieldPtrOrWrapper.(type) {
+	case filters.SqlWrapper, filters.MultiSqlWrapper, filters.DialectWrapper:
+		return nil
+	}
+	_, err := plan.colMap.LocateTableAndColumn(fieldPtrOrWrapper)
+	return err
+}
+
+// groupByValues returns the query strings for values, appending any
+// arguments to statement.
+func (plan *QueryPlan) groupByValues(statement *Statement, values []interface{}) ([]string, error) {
+	sqlValues := make([]string, 0, len(values))
+	for _, value := range values {
+		args, sqlValue, err := plan.argOrColumn(value)
+		if err != nil {
+			return nil, err
+		}
+		sqlValues = append(sqlValues, sqlValue)
+		statement.args = append(statement.args, args...)
+	}
+	return sqlValues, nil
+}
+
+// groupBy returns the string for this grouping element, appending any
+// arguments to statement.
+func (g grouping) groupBy(plan *QueryPlan, statement *Statement) (string, error) {
+	sets := make([]string, 0, len(g.sets))
+	for _, set := range g.sets {
+		values, err := plan.groupByValues(statement, set)
+		if err != nil {
+			return "", err
+		}
+		sets = append(sets, "("+strings.Join(values, ", ")+")")
+	}
+	if g.kind == "GROUPING SETS" {
+		return g.kind + " (" + strings.Join(sets, ", ") + ")", nil
+	}
+	return g.kind + sets[0], nil
+}
+
+// addGrouping adds a grouping of sets to plan, if the dialect supports
+// it.
+func (plan *QueryPlan) addGrouping(kind string, sets ...[]interface{}) interfaces.SelectQuery {
+	switch plan.dbMap.Dialect.(type) {
+	case dialects.SqliteDialect:
+		plan.Errors = append(plan.Errors, errors.New("gorp: SQLite does not support "+kind))
+		return plan
+	case dialects.MySQLDialect:
+		if kind != "ROLLUP" {
+			plan.Errors = append(plan.Errors, errors.New("gorp: MySQL does not support "+kind))
+			return plan
+		}
+	}
+	for _, set := range sets {
+		for _, fieldPtrOrWrapper := range set {
+			if err := plan.checkGroupBy(fieldPtrOrWrapper); err != nil {
+				plan.Errors = append(plan.Errors, err)
+				return plan
+			}
+		}
+	}
+	plan.groupings = append(plan.groupings, grouping{kind: kind, sets: sets})
+	return plan
+}
+
+// GroupByRollup adds a ROLLUP of fieldPtrs to the group by clause,
+// which adds subtotal rows for each prefix of fieldPtrs along with a
+// grand total row.  Any of the fieldPtrs may instead be a
+// SqlWrapper.  MySQL's WITH ROLLUP syntax is used for MySQL
+// dialects, which means that on MySQL, it can't be combined with
+// other group by fields.
+func (plan *QueryPlan) GroupByRollup(fieldPtrs ...interface{}) interfaces.SelectQuery {
+	if len(fieldPtrs) == 0 {
+		plan.Errors = append(plan.Errors, errors.New("gorp: GroupByRollup requires at least one field"))
+		return plan
+	}
+	return plan.addGrouping("ROLLUP", fieldPtrs)
+}
+
+// GroupByCube adds a CUBE of fieldPtrs to the group by clause, which
+// adds subtotal rows for every combination of fieldPtrs.
+func (plan *QueryPlan) GroupByCube(fieldPtrs ...interface{}) interfaces.SelectQuery {
+	if len(fieldPtrs) == 0 {
+		plan.Errors = append(plan.Errors, errors.New("gorp: GroupByCube requires at least one field"))
+		return plan
+	}
+	return plan.addGrouping("CUBE", fieldPtrs)
+}
+
+// GroupingSets adds GROUPING SETS to the group by clause.  Each set
+// is a slice of field pointers to group by; an empty set represents
+// the grand total.  For example, the following would select totals
+// for each status, each owner, and all rows:
+//
+//     query.GroupingSets(
+//         []interface{}{&ref.Status},
+//         []interface{}{&ref.OwnerId},
+//         []interface{}{},
+//     )
+func (plan *QueryPlan) GroupingSets(sets ...[]interface{}) interfaces.SelectQuery {
+	if len(sets) == 0 {
+		plan.Errors = append(plan.Errors, errors.New("gorp: GroupingSets requires at least one set"))
+		return plan
+	}
+	return plan.addGrouping("GROUPING SETS", sets...)
+}
+
+// addGroupByClause adds the group by clause (including the words
+// "GROUP BY") to statement, if there is a group by clause on plan.
+func (plan *QueryPlan) addGroupByClause(statement *Statement) error {
+	if _, isMySQL := plan.dbMap.Dialect.(dialects.MySQLDialect); isMySQL && len(plan.groupings) > 0 {
+		if len(plan.groupings) > 1 || len(plan.groupBy) > 0 {
+			return errors.New("gorp: MySQL's WITH ROLLUP cannot be combined with other group by fields")
+		}
+		values, err := plan.groupByValues(statement, plan.groupings[0].sets[0])
+		if err != nil {
+			return err
+		}
+		statement.query.WriteString(" GROUP BY " + strings.Join(values, ", ") + " WITH ROLLUP")
+		return nil
+	}
+	elements, err := plan.groupByValues(statement, plan.groupBy)
+	if err != nil {
+		return err
+	}
+	for _, g := range plan.groupings {
+		element, err := g.groupBy(plan, statement)
+		if err != nil {
+			return err
+		}
+		elements = append(elements, element)
+	}
+	if len(elements) > 0 {
+		statement.query.WriteString(" GROUP BY " + strings.Join(elements, ", "))
+	}
+	return nil
+}
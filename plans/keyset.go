@@ -0,0 +1,226 @@
+package plans
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/filters"
+)
+
+// rowValueFilter compares two row values, e.g. (a, b) > (x, y).
+type rowValueFilter struct {
+	left       []interface{}
+	comparison string
+	right      []interface{}
+}
+
+func (filter *rowValueFilter) ActualValues() []interface{} {
+	return append(append([]interface{}{}, filter.left...), filter.right...)
+}
+
+func (filter *rowValueFilter) Where(values ...string) string {
+	count := len(filter.left)
+	return "(" + strings.Join(values[:count], ", ") + ")" + filter.comparison +
+		"(" + strings.Join(values[count:], ", ") + ")"
+}
+
+// keysetKey is a field that keyset pagination orders by.
+type keysetKey struct {
+	fieldPtr   interface{}
+	descending bool
+
+	// nulls should be "FIRST", "LAST", or empty, as in order.
+	nulls string
+}
+
+// keysetKeys returns the keys to paginate by.  The direction of each
+// key is taken from the OrderBy list, and if orderFields is empty,
+// the fields in the OrderBy list are used.
+func (plan *QueryPlan) keysetKeys(orderFields []interface{}) ([]keysetKey, error) {
+	if len(orderFields) == 0 {
+		for _, orderBy := range plan.orderBy {
+			orderFields = append(orderFields, orderBy.ActualValue())
+		}
+	}
+	if len(orderFields) == 0 {
+		return nil, errors.New("gorp: KeysetPage requires at least one field to order by")
+	}
+	keys := make([]keysetKey, 0, len(orderFields))
+	for _, field := range orderFields {
+		if _, err := plan.colMap.fieldMapForPointer(field); err != nil {
+			return nil, err
+		}
+		key := keysetKey{fieldPtr: field}
+		for _, orderBy := range plan.orderBy {
+			if orderBy.ActualValue() != field {
+				continue
+			}
+			key.descending = orderBy.direction == "DESC"
+			key.nulls = orderBy.nulls
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// keysetFilter returns a filter matching rows that sort after
+// afterValues.  When every key sorts in the same direction, a row
+// value comparison is used; otherwise, the comparison is expanded to
+// (a > x) OR (a = x AND b > y) OR ...
+func (plan *QueryPlan) keysetFilter(keys []keysetKey, afterValues []interface{}) filters.Filter {
+	comparison := func(key keysetKey, value interface{}) filters.Filter {
+		if key.descending {
+			return filters.Less(key.fieldPtr, value)
+		}
+		return filters.Greater(key.fieldPtr, value)
+	}
+	uniform := true
+	for _, key := range keys[1:] {
+		uniform = uniform && key.descending == keys[0].descending
+	}
+	// MySQL supports row values, but can't use indexes for them.
+	_, isMySQL := plan.dbMap.Dialect.(dialects.MySQLDialect)
+	if len(keys) > 1 && uniform && !isMySQL {
+		rowFilter := &rowValueFilter{comparison: ">", right: afterValues}
+		if keys[0].descending {
+			rowFilter.comparison = "<"
+		}
+		for _, key := range keys {
+			rowFilter.left = append(rowFilter.left, key.fieldPtr)
+		}
+		return rowFilter
+	}
+	expanded := make([]filters.Filter, 0, len(keys))
+	for i, key := range keys {
+		matches := make([]filters.Filter, 0, i+1)
+		for j := 0; j < i; j++ {
+			matches = append(matches, filters.Equal(keys[j].fieldPtr, afterValues[j]))
+		}
+		matches = append(matches, comparison(key, afterValues[i]))
+		expanded = append(expanded, filters.And(matches...))
+	}
+	return filters.Or(expanded...)
+}
+
+// encodeCursor encodes values as an opaque cursor token.
+func encodeCursor(values []interface{}) (string, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// decodeCursor decodes a cursor token created by encodeCursor,
+// converting each value to the type of its key's field.
+func decodeCursor(keys []keysetKey, cursor string) ([]interface{}, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("gorp: Invalid cursor: %s", err)
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(decoded, &raw); err != nil {
+		return nil, fmt.Errorf("gorp: Invalid cursor: %s", err)
+	}
+	if len(raw) != len(keys) {
+		return nil, errors.New("gorp: Invalid cursor: the number of values does not match the number of keys")
+	}
+	values := make([]interface{}, 0, len(keys))
+	for i, key := range keys {
+		value := reflect.New(reflect.TypeOf(key.fieldPtr).Elem())
+		if err := json.Unmarshal(raw[i], value.Interface()); err != nil {
+			return nil, fmt.Errorf("gorp: Invalid cursor: %s", err)
+		}
+		values = append(values, value.Elem().Interface())
+	}
+	return values, nil
+}
+
+// KeysetPage will run this query plan as a SELECT statement, returning
+// at most limit rows that sort after afterValues.  Unlike Offset, the
+// page is located using a comparison against the key fields, so it
+// stays fast and stable on large tables.
+//
+// The orderFields must be field pointers; their directions are taken
+// from the OrderBy list, and if orderFields is empty, the fields in
+// the OrderBy list are used.  The orderFields should uniquely
+// identify a row (e.g. by ending with the primary key) and should not
+// be null.  afterValues may be nil (for the first page), a slice with
+// one value per key field, or a cursor returned by a previous call.
+// The returned cursor will be empty when there are no more pages.
+//
+//     results, cursor, err := dbMap.Query(ref).
+//         OrderBy(&ref.CreatedAt, "DESC").
+//         OrderBy(&ref.Id, "DESC").
+//         KeysetPage(nil, previousCursor, 50)
+func (plan *QueryPlan) KeysetPage(orderFields []interface{}, afterValues interface{}, limit int64) (results []interface{}, cursor string, err error) {
+	if len(plan.Errors) > 0 {
+		return nil, "", plan.Errors[0]
+	}
+	if limit <= 0 {
+		return nil, "", errors.New("gorp: KeysetPage requires a positive limit")
+	}
+	keys, err := plan.keysetKeys(orderFields)
+	if err != nil {
+		return nil, "", err
+	}
+	var after []interface{}
+	switch src := afterValues.(type) {
+	case nil:
+	case string:
+		if after, err = decodeCursor(keys, src); err != nil {
+			return nil, "", err
+		}
+	case []interface{}:
+		if len(src) != len(keys) {
+			return nil, "", errors.New("gorp: KeysetPage requires one after value per key field")
+		}
+		after = src
+	default:
+		return nil, "", fmt.Errorf("gorp: KeysetPage cannot use %T as after values", afterValues)
+	}
+
+	plan.storeJoin()
+	// Select one extra row to find out whether there is a next page.
+	plan = plan.withPage(limit+1, 0)
+	if after != nil {
+		pageFilter := new(filters.AndFilter)
+		if plan.filters != nil {
+			pageFilter.Add(plan.filters)
+		}
+		pageFilter.Add(plan.keysetFilter(keys, after))
+		plan.filters = pageFilter
+	}
+	plan.orderBy = make([]order, 0, len(keys))
+	for _, key := range keys {
+		o := order{fieldOrWrapper: key.fieldPtr, direction: "ASC", nulls: key.nulls}
+		if key.descending {
+			o.direction = "DESC"
+		}
+		plan.orderBy = append(plan.orderBy, o)
+	}
+
+	results, err = plan.Select()
+	if err != nil || int64(len(results)) <= limit {
+		return results, "", err
+	}
+	results = results[:limit]
+
+	ref := plan.referenceTarget()
+	last := reflect.ValueOf(results[limit-1]).Elem()
+	lastValues := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		index, err := resultFieldIndex(last.Type(), ref.Elem(), key.fieldPtr)
+		if err != nil {
+			return nil, "", err
+		}
+		lastValues = append(lastValues, last.FieldByIndex(index).Interface())
+	}
+	cursor, err = encodeCursor(lastValues)
+	return results, cursor, err
+}
@@ -0,0 +1,159 @@
+package plans
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/interfaces"
+)
+
+// A lock is a row locking clause for a select statement.
+type lock struct {
+	// strength should be "UPDATE" or "SHARE".
+	strength string
+
+	// tables should be the quoted names of the tables to lock.  If
+	// empty, all tables in the query are locked.
+	tables []string
+
+	// wait should be "NOWAIT", "SKIP LOCKED", or empty.
+	wait string
+}
+
+// lockTable returns the name to use for tableRef in a locking
+// clause.  The tableRef must be the reference struct of the query or
+// of a joined table.  Tables are locked using the name
+// that their columns are referenced by in the query, so aliased
+// tables are locked by their alias; other tables are locked by their
+// unqualified name.
+func (plan *QueryPlan) lockTable(tableRef interface{}) (string, error) {
+	refVal := reflect.ValueOf(tableRef)
+	if refVal.Kind() != reflect.Ptr || refVal.Elem().Kind() != reflect.Struct {
+		return "", errors.New("gorp: Locked tables must be passed in as pointers to reference structs")
+	}
+	table, err := plan.dbMap.TableFor(refVal.Type().Elem(), false)
+	if err != nil {
+		return "", err
+	}
+	quotedTable := plan.dbMap.Dialect.QuotedTableForQuery(table.SchemaName, table.TableName)
+	start := refVal.Pointer()
+	end := start + refVal.Type().Elem().Size()
+	for _, fieldMap := range plan.colMap {
+		addr := reflect.ValueOf(fieldMap.addr).Pointer()
+		if addr < start || addr >= end {
+			continue
+		}
+		if fieldMap.quotedTable != quotedTable {
+			return fieldMap.quotedTable, nil
+		}
+		return plan.dbMap.Dialect.QuoteField(table.TableName), nil
+	}
+	return "", errors.New("gorp: Cannot lock table " + quotedTable + ", which is not part of the query")
+}
+
+// setLock sets the locking clause for plan, checking that the dialect
+// and executor support row locking.
+func (plan *QueryPlan) setLock(strength string, tableRefs []interface{}) interfaces.SelectQuery {
+	if _, isSqlite := plan.dbMap.Dialect.(dialects.SqliteDialect); isSqlite {
+		plan.Errors = append(plan.Errors, errors.New("gorp: SQLite does not support row locking"))
+		return plan
+	}
+	if _, isTransaction := plan.executor.(*gorp.Transaction); !isTransaction {
+		plan.Errors = append(plan.Errors, errors.New("gorp: Row locks can only be used within a transaction"))
+		return plan
+	}
+	if plan.lock != nil {
+		plan.Errors = append(plan.Errors, errors.New("gorp: A query may only have one locking clause"))
+		return plan
+	}
+	plan.lock = &lock{strength: strength}
+	for _, tableRef := range tableRefs {
+		table, err := plan.lockTable(tableRef)
+		if err != nil {
+			plan.Errors = append(plan.Errors, err)
+			return plan
+		}
+		plan.lock.tables = append(plan.lock.tables, table)
+	}
+	return plan
+}
+
+// setLockWait sets the wait behavior of plan's locking clause.
+func (plan *QueryPlan) setLockWait(wait string) interfaces.SelectQuery {
+	if plan.lock == nil {
+		plan.Errors = append(plan.Errors, errors.New("gorp: "+wait+" requires a FOR UPDATE or FOR SHARE clause"))
+		return plan
+	}
+	if plan.lock.wait != "" {
+		plan.Errors = append(plan.Errors, errors.New("gorp: Cannot use both NOWAIT and SKIP LOCKED"))
+		return plan
+	}
+	plan.lock.wait = wait
+	return plan
+}
+
+// ForUpdate adds a FOR UPDATE clause to the query, locking the
+// selected rows until the end of the transaction.  If any tableRefs
+// are passed in, they must be the reference structs of the query or
+// of joined tables, and only rows from those tables will be locked.
+// Row locks are not supported by SQLite, and can only be used when
+// the query was created from a transaction.  For example:
+//
+//     tx, err := dbMap.Begin()
+//     ...
+//     jobs, err := tx.Query(job).
+//         Where().
+//         Null(&job.StartedAt).
+//         Limit(10).
+//         ForUpdate().
+//         SkipLocked().
+//         Select()
+func (plan *QueryPlan) ForUpdate(tableRefs ...interface{}) interfaces.SelectQuery {
+	return plan.setLock("UPDATE", tableRefs)
+}
+
+// ForShare adds a FOR SHARE clause to the query.  It works the same
+// way as ForUpdate, except that the rows are locked in share mode.
+// MySQL only supports FOR SHARE in 8.0 or newer, so for MySQL, a
+// LOCK IN SHARE MODE clause (which 5.7 understands) is used instead,
+// unless tableRefs, SkipLocked, or NoWait require MySQL 8.0.
+func (plan *QueryPlan) ForShare(tableRefs ...interface{}) interfaces.SelectQuery {
+	return plan.setLock("SHARE", tableRefs)
+}
+
+// SkipLocked causes the query's locking clause to skip any rows that
+// are already locked, instead of waiting for them.
+func (plan *QueryPlan) SkipLocked() interfaces.SelectQuery {
+	return plan.setLockWait("SKIP LOCKED")
+}
+
+// NoWait causes the query's locking clause to return an error if any
+// rows are already locked, instead of waiting for them.
+func (plan *QueryPlan) NoWait() interfaces.SelectQuery {
+	return plan.setLockWait("NOWAIT")
+}
+
+// addLockingClause adds the locking clause, if any, to statement.
+func (plan *QueryPlan) addLockingClause(statement *Statement) {
+	if plan.lock == nil {
+		return
+	}
+	_, isMySQL := plan.dbMap.Dialect.(dialects.MySQLDialect)
+	if isMySQL && plan.lock.strength == "SHARE" && len(plan.lock.tables) == 0 && plan.lock.wait == "" {
+		statement.query.WriteString(" LOCK IN SHARE MODE")
+		return
+	}
+	statement.query.WriteString(" FOR ")
+	statement.query.WriteString(plan.lock.strength)
+	if len(plan.lock.tables) > 0 {
+		statement.query.WriteString(" OF ")
+		statement.query.WriteString(strings.Join(plan.lock.tables, ", "))
+	}
+	if plan.lock.wait != "" {
+		statement.query.WriteString(" ")
+		statement.query.WriteString(plan.lock.wait)
+	}
+}
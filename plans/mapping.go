@@ -76,17 +76,16 @@ func (plan *QueryPlan) mapColumns(table *gorp.TableMap, value reflect.Value) (in
 	for i := 0; i < value.NumField(); i++ {
 		fieldType := valueType.Field(i)
 		fieldVal := value.Field(i)
-		if fieldType.Anonymous {
-			if fieldVal.Kind() != reflect.Ptr {
-				fieldVal = fieldVal.Addr()
-			} else if fieldVal.IsNil() {
-				// embedded types must be initialized for querying
-				fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
-			}
-			count, _ := plan.mapColumns(table, fieldVal)
+		if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct {
+			// gorp only maps the fields of embedded structs, not
+			// embedded struct pointers.
+			count, _ := plan.mapColumns(table, fieldVal.Addr())
 			queryableFields += count
 		} else if fieldType.PkgPath == "" {
-			col := table.ColMap(fieldType.Name)
+			col := colMapOrNil(table, fieldType.Name)
+			if col == nil {
+				continue
+			}
 			quotedCol := plan.dbMap.Dialect.QuoteField(col.ColumnName)
 			fieldMap := fieldColumnMap{
 				addr:         fieldVal.Addr().Interface(),
@@ -1,8 +1,37 @@
 package plans
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/dialects"
+)
+
 type order struct {
 	fieldOrWrapper interface{}
-	direction      string
+
+	// direction should be "ASC", "DESC", or empty.
+	direction string
+
+	// nulls should be "FIRST", "LAST", or empty.
+	nulls string
+}
+
+// newOrder parses direction and returns an order for fieldOrWrapper.
+func newOrder(fieldOrWrapper interface{}, direction string) (order, error) {
+	o := order{fieldOrWrapper: fieldOrWrapper}
+	words := strings.Fields(strings.ToUpper(direction))
+	if len(words) > 0 && (words[0] == "ASC" || words[0] == "DESC") {
+		o.direction, words = words[0], words[1:]
+	}
+	if len(words) == 2 && words[0] == "NULLS" && (words[1] == "FIRST" || words[1] == "LAST") {
+		o.nulls, words = words[1], nil
+	}
+	if len(words) > 0 {
+		return o, fmt.Errorf("gorp: Invalid order by direction %q", direction)
+	}
+	return o, nil
 }
 
 // ActualValue returns the actual value requested as the order
@@ -13,10 +42,30 @@ func (o order) ActualValue() interface{} {
 }
 
 // OrderBy returns the string that should follow " ORDER BY " in a
-// query, using sqlValue as the value to order by.
-func (o order) OrderBy(sqlValue string) string {
+// query, using sqlValue as the value to order by.  Dialects that
+// don't support NULLS FIRST and NULLS LAST have them emulated with an
+// extra sort key, so uses is the number of times that sqlValue is
+// used in the returned string.
+func (o order) OrderBy(dialect gorp.Dialect, sqlValue string) (orderBy string, uses int) {
+	orderBy = sqlValue
 	if o.direction != "" {
-		sqlValue += " " + o.direction
+		orderBy += " " + o.direction
+	}
+	if o.nulls == "" {
+		return orderBy, 1
+	}
+	var isNull string
+	switch dialect.(type) {
+	case dialects.MySQLDialect:
+		isNull = "ISNULL(" + sqlValue + ")"
+	case dialects.SqliteDialect:
+		// NULLS FIRST and NULLS LAST were only added in SQLite 3.30.
+		isNull = sqlValue + " IS NULL"
+	default:
+		return orderBy + " NULLS " + o.nulls, 1
+	}
+	if o.nulls == "FIRST" {
+		isNull += " DESC"
 	}
-	return sqlValue
+	return isNull + ", " + orderBy, 2
 }
@@ -0,0 +1,98 @@
+package plans
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/nelsam/gorq/dialects"
+)
+
+// totalCount returns the number of rows matching this query plan,
+// ignoring any limit, offset, or order by clauses.  Grouped queries
+// are counted by group.
+func (plan *QueryPlan) totalCount() (int64, error) {
+	plan = plan.withoutPaging()
+	statement := new(Statement)
+	if len(plan.groupBy) == 0 && len(plan.groupings) == 0 {
+		statement.query.WriteString("SELECT COUNT(*)")
+		if err := plan.addSelectSuffix(statement); err != nil {
+			return -1, err
+		}
+	} else {
+		statement.query.WriteString("SELECT COUNT(*) FROM (SELECT 1")
+		if err := plan.addSelectSuffix(statement); err != nil {
+			return -1, err
+		}
+		statement.query.WriteString(") AS ")
+		statement.query.WriteString(plan.dbMap.Dialect.QuoteField("grouped_rows"))
+	}
+	bindVars := plan.bindVars(statement)
+	return plan.executor.SelectInt(statement.Query(bindVars...), statement.args...)
+}
+
+// selectWithTotal runs this query plan as a SELECT statement, using
+// COUNT(*) OVER() to load the total number of matching rows (before
+// any limit or offset is applied) alongside the results.  The total
+// will be zero if no rows are returned.
+func (plan *QueryPlan) selectWithTotal() (results []interface{}, total int64, err error) {
+	if len(plan.Errors) > 0 {
+		return nil, 0, plan.Errors[0]
+	}
+	statement := new(Statement)
+	statement.query.WriteString("SELECT ")
+	if err := plan.addSelectColumns(statement); err != nil {
+		return nil, 0, err
+	}
+	statement.query.WriteString(",COUNT(*) OVER()")
+	if err := plan.addSelectSuffix(statement); err != nil {
+		return nil, 0, err
+	}
+	bindVars := plan.bindVars(statement)
+	rows, err := plan.queryRows(plan.referenceTarget(), statement.Query(bindVars...), statement.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	rows.extra = []interface{}{&total}
+	for rows.Next() {
+		result := reflect.New(rows.rowType)
+		if err := rows.Scan(result.Interface()); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, result.Interface())
+	}
+	return results, total, rows.Err()
+}
+
+// Paginate will run this query plan as a SELECT statement for a
+// single page of results, returning the results along with the total
+// number of matching rows.  Pages start at 1, and any limit or offset
+// on the query is ignored.  The total ignores limit, offset, and
+// order by clauses.
+//
+// Dialects that support window functions load the total using
+// COUNT(*) OVER() in the same query; a separate COUNT query is only
+// needed when the page is empty, the dialect is MySQL, or the query
+// has a locking clause.
+func (plan *QueryPlan) Paginate(page, perPage int64) (results []interface{}, total int64, err error) {
+	if page < 1 || perPage < 1 {
+		return nil, 0, errors.New("gorp: Paginate requires a page and page size of at least 1")
+	}
+	plan = plan.withPage(perPage, (page-1)*perPage)
+
+	// Window functions are only available in MySQL 8 and above, and
+	// can't be used with row locks.
+	if _, isMySQL := plan.dbMap.Dialect.(dialects.MySQLDialect); isMySQL || plan.lock != nil {
+		results, err = plan.Select()
+	} else {
+		results, total, err = plan.selectWithTotal()
+	}
+	if err != nil || total > 0 {
+		return results, total, err
+	}
+	total, err = plan.totalCount()
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
@@ -1,15 +1,28 @@
 package plans
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/go-gorp/gorp"
 	"github.com/nelsam/gorq/filters"
 	"github.com/nelsam/gorq/interfaces"
 )
 
+var (
+	// ErrNoRows is returned by First and One when there are no
+	// matching rows.  It is the same value as sql.ErrNoRows.
+	ErrNoRows = sql.ErrNoRows
+
+	// ErrMultipleRows is returned by One when there is more than one
+	// matching row.
+	ErrMultipleRows = errors.New("gorp: More than one row matched a query that expected one row")
+)
+
 // BindVarPlaceholder is used as a placeholder string for bindVar
 // strings.  Wherever it is used in a query, it should be replaced by
 // the correct bind variable for the dialect in use.
@@ -49,21 +62,24 @@ type QueryPlan struct {
 	// returned immediately.
 	Errors []error
 
-	table          *gorp.TableMap
-	dbMap          *gorp.DbMap
-	quotedTable    string
-	executor       gorp.SqlExecutor
-	target         reflect.Value
-	colMap         structColumnMap
-	joins          []*filters.JoinFilter
-	assignCols     []string
-	assignBindVars []string
-	assignArgs     []interface{}
-	filters        filters.MultiFilter
-	orderBy        []order
-	groupBy        []string
-	limit          int64
-	offset         int64
+	table        *gorp.TableMap
+	dbMap        *gorp.DbMap
+	quotedTable  string
+	executor     gorp.SqlExecutor
+	target       reflect.Value
+	colMap       structColumnMap
+	joins        []*filters.JoinFilter
+	assignCols   []string
+	assignValues []interface{}
+	filters      filters.MultiFilter
+	orderBy      []order
+	selectAs     []selectAs
+	selectExprs  []selectAs
+	groupBy      []interface{}
+	groupings    []grouping
+	limit        int64
+	offset       int64
+	lock         *lock
 }
 
 // Extend returns an extended query, using extensions for the
@@ -94,7 +110,15 @@ func (plan *QueryPlan) Extend() interface{} {
 
 // Assign sets up an assignment operation to assign the passed in
 // value to the passed in field pointer.  This is used for creating
-// UPDATE or INSERT queries.
+// UPDATE or INSERT queries.  The value may be anything that can be
+// used in a where clause, so field pointers, SqlWrappers, and CASE
+// expressions can be used to assign computed values, e.g.:
+//
+//     count, err := dbMap.Query(ref).
+//         Assign(&ref.Views, gorq.Add(&ref.Views, 1)).
+//         Where().
+//         Equal(&ref.Id, id).
+//         Update()
 func (plan *QueryPlan) Assign(fieldPtr interface{}, value interface{}) interfaces.AssignQuery {
 	assignPlan := &AssignQueryPlan{QueryPlan: plan}
 	return assignPlan.Assign(fieldPtr, value)
@@ -229,9 +253,15 @@ func (plan *QueryPlan) False(fieldPtr interface{}) interfaces.WhereQuery {
 
 // OrderBy adds a column to the order by clause.  The direction is
 // optional - you may pass in an empty string to order in the default
-// direction for the given column.
+// direction for the given column.  Unknown directions are reported
+// as errors.
 func (plan *QueryPlan) OrderBy(fieldPtrOrWrapper interface{}, direction string) interfaces.SelectQuery {
-	plan.orderBy = append(plan.orderBy, order{fieldPtrOrWrapper, direction})
+	o, err := newOrder(fieldPtrOrWrapper, direction)
+	if err != nil {
+		plan.Errors = append(plan.Errors, err)
+		return plan
+	}
+	plan.orderBy = append(plan.orderBy, o)
 	return plan
 }
 
@@ -241,14 +271,14 @@ func (plan *QueryPlan) DiscardOrderBy() interfaces.SelectQuery {
 	return plan
 }
 
-// GroupBy adds a column to the group by clause.
-func (plan *QueryPlan) GroupBy(fieldPtr interface{}) interfaces.SelectQuery {
-	column, err := plan.colMap.LocateTableAndColumn(fieldPtr)
-	if err != nil {
+// GroupBy adds a column or expression to the group by clause.  The
+// fieldPtrOrWrapper may be a field pointer or any type of SqlWrapper.
+func (plan *QueryPlan) GroupBy(fieldPtrOrWrapper interface{}) interfaces.SelectQuery {
+	if err := plan.checkGroupBy(fieldPtrOrWrapper); err != nil {
 		plan.Errors = append(plan.Errors, err)
 		return plan
 	}
-	plan.groupBy = append(plan.groupBy, column)
+	plan.groupBy = append(plan.groupBy, fieldPtrOrWrapper)
 	return plan
 }
 
@@ -283,6 +313,14 @@ func (plan *QueryPlan) DiscardOffset() interfaces.SelectQuery {
 // string will be the bind value.
 func (plan *QueryPlan) argOrColumn(value interface{}) (args []interface{}, sqlValue string, err error) {
 	switch src := value.(type) {
+	case nil:
+		return []interface{}{nil}, BindVarPlaceholder, nil
+	case filters.DialectWrapper:
+		dialectVal, err := src.ForDialect(plan.dbMap.Dialect)
+		if err != nil {
+			return nil, "", err
+		}
+		return plan.argOrColumn(dialectVal)
 	case filters.SqlWrapper:
 		var wrapperVal string
 		args, wrapperVal, err = plan.argOrColumn(src.ActualValue())
@@ -303,9 +341,30 @@ func (plan *QueryPlan) argOrColumn(value interface{}) (args []interface{}, sqlVa
 			args = append(args, newArgs...)
 		}
 		return args, src.WrapSql(wrapperVals...), nil
+	case filters.Filter:
+		values := src.ActualValues()
+		whereVals := make([]string, 0, len(values))
+		args := make([]interface{}, 0, len(values))
+		for _, val := range values {
+			newArgs, whereVal, err := plan.argOrColumn(val)
+			if err != nil {
+				return nil, "", err
+			}
+			whereVals = append(whereVals, whereVal)
+			args = append(args, newArgs...)
+		}
+		return args, src.Where(whereVals...), nil
+	case filters.BoundValue:
+		return []interface{}{src.Value}, BindVarPlaceholder, nil
+	case filters.ColumnRef:
+		sqlValue, err = plan.colMap.LocateTableAndColumn(src.FieldPtr)
+		return nil, sqlValue, err
 	default:
 		if reflect.TypeOf(value).Kind() == reflect.Ptr {
 			sqlValue, err = plan.colMap.LocateTableAndColumn(value)
+			if _, notFound := err.(*FieldNotFoundError); notFound {
+				return plan.nonFieldPointer(value, err)
+			}
 		} else {
 			sqlValue = BindVarPlaceholder
 			args = append(args, value)
@@ -314,6 +373,42 @@ func (plan *QueryPlan) argOrColumn(value interface{}) (args []interface{}, sqlVa
 	return
 }
 
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// nonFieldPointer handles pointer values that don't point to any
+// field in the query's reference structs.  Pointers to values that
+// can be passed to the database (e.g. *string or *time.Time) will be
+// used as bind arguments, even if they point to a field of a
+// reference struct that isn't part of the query; use filters.Col to
+// get an error in that case instead.  Anything else is most likely a
+// mistake, so notFoundErr will be returned with some details.
+func (plan *QueryPlan) nonFieldPointer(value interface{}, notFoundErr error) (args []interface{}, sqlValue string, err error) {
+	ptrType := reflect.TypeOf(value)
+	if ptrType.Implements(valuerType) {
+		return []interface{}{value}, BindVarPlaceholder, nil
+	}
+	elemType := ptrType.Elem()
+	switch elemType.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []interface{}{value}, BindVarPlaceholder, nil
+	case reflect.Slice:
+		if elemType.Elem().Kind() == reflect.Uint8 {
+			return []interface{}{value}, BindVarPlaceholder, nil
+		}
+	case reflect.Struct:
+		if elemType == timeType || elemType.Implements(valuerType) {
+			return []interface{}{value}, BindVarPlaceholder, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%s: values of type %s can't be bound as arguments, so it must point to a field "+
+		"(use filters.Value or filters.Col to be explicit)", notFoundErr, ptrType)
+}
+
 // Truncate will run this query plan as a TRUNCATE TABLE statement.
 func (plan *QueryPlan) Truncate() error {
 	query := fmt.Sprintf("TRUNCATE TABLE %s", plan.QuotedTable())
@@ -335,16 +430,17 @@ func (plan *QueryPlan) runSelect(target interface{}) ([]interface{}, error) {
 		return nil, err
 	}
 	bindVars := plan.bindVars(statement)
+	if len(plan.selectExprs) > 0 {
+		// gorp won't populate transient fields, so we have to do
+		// the scanning ourselves.
+		return plan.scanSelect(target, statement.Query(bindVars...), statement.args...)
+	}
 	return plan.executor.Select(target, statement.Query(bindVars...), statement.args...)
 }
 
 // Select will run this query plan as a SELECT statement.
 func (plan *QueryPlan) Select() ([]interface{}, error) {
-	target := plan.target.Interface()
-	if subQuery, ok := target.(subQuery); ok {
-		target = subQuery.getTarget().Interface()
-	}
-	return plan.runSelect(target)
+	return plan.runSelect(plan.referenceTarget().Interface())
 }
 
 // SelectToTarget will run this query plan as a SELECT statement, and
@@ -358,6 +454,102 @@ func (plan *QueryPlan) SelectToTarget(target interface{}) error {
 	return err
 }
 
+// withPage returns a shallow copy of this query plan with its limit
+// and offset replaced, so that terminal methods can run a page of the
+// query without changing the plan they were called on.  Slices in the
+// copy are shared with plan, so they must be replaced rather than
+// modified in place.
+func (plan *QueryPlan) withPage(limit, offset int64) *QueryPlan {
+	paged := *plan
+	paged.limit, paged.offset = limit, offset
+	return &paged
+}
+
+// withoutPaging returns a shallow copy of this query plan with no
+// order by, limit, or offset clauses.
+func (plan *QueryPlan) withoutPaging() *QueryPlan {
+	unpaged := plan.withPage(0, 0)
+	unpaged.orderBy = nil
+	return unpaged
+}
+
+// withoutGrouping returns a shallow copy of this query plan with no
+// group by, order by, limit, or offset clauses.
+func (plan *QueryPlan) withoutGrouping() *QueryPlan {
+	ungrouped := plan.withoutPaging()
+	ungrouped.groupBy, ungrouped.groupings = nil, nil
+	return ungrouped
+}
+
+// referenceTarget returns the reference that selected rows are
+// scanned into, unwrapping sub-queries.
+func (plan *QueryPlan) referenceTarget() reflect.Value {
+	if subQuery, ok := plan.target.Interface().(subQuery); ok {
+		return subQuery.getTarget()
+	}
+	return plan.target
+}
+
+// selectLimited runs this query plan as a SELECT statement, limited
+// to at most limit rows.  The plan's own limit is left untouched.
+func (plan *QueryPlan) selectLimited(limit int64) ([]interface{}, error) {
+	if plan.limit != 0 && plan.limit <= limit {
+		return plan.Select()
+	}
+	return plan.withPage(limit, plan.offset).Select()
+}
+
+// First will run this query plan as a SELECT statement with a limit
+// of 1, and return the resulting row.  If there are no resulting
+// rows, ErrNoRows will be returned.
+func (plan *QueryPlan) First() (interface{}, error) {
+	results, err := plan.selectLimited(1)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoRows
+	}
+	return results[0], nil
+}
+
+// One will run this query plan as a SELECT statement and return the
+// resulting row.  ErrNoRows will be returned if there are no
+// resulting rows, and ErrMultipleRows will be returned if there is
+// more than one.
+func (plan *QueryPlan) One() (interface{}, error) {
+	results, err := plan.selectLimited(2)
+	if err != nil {
+		return nil, err
+	}
+	switch len(results) {
+	case 0:
+		return nil, ErrNoRows
+	case 1:
+		return results[0], nil
+	default:
+		return nil, ErrMultipleRows
+	}
+}
+
+// Exists will run this query plan as a SELECT EXISTS statement,
+// returning whether or not any rows match the query.
+func (plan *QueryPlan) Exists() (bool, error) {
+	if len(plan.Errors) > 0 {
+		return false, plan.Errors[0]
+	}
+	statement := new(Statement)
+	statement.query.WriteString("SELECT EXISTS(SELECT 1")
+	if err := plan.addSelectSuffix(statement); err != nil {
+		return false, err
+	}
+	statement.query.WriteString(")")
+	bindVars := plan.bindVars(statement)
+	var exists bool
+	err := plan.executor.QueryRow(statement.Query(bindVars...), statement.args...).Scan(&exists)
+	return exists, err
+}
+
 func (plan *QueryPlan) Count() (int64, error) {
 	statement := new(Statement)
 	statement.query.WriteString("SELECT COUNT(*)")
@@ -375,13 +567,27 @@ func (plan *QueryPlan) QuotedTable() string {
 	return plan.quotedTable
 }
 
+// assignValue returns the query string for the value assigned to the
+// column at index, appending any arguments to statement.  Assigned
+// values are handled the same way as values in a where clause, so
+// they may be field pointers, SqlWrappers, or values to bind as
+// arguments.
+func (plan *QueryPlan) assignValue(statement *Statement, index int) (string, error) {
+	args, sqlValue, err := plan.argOrColumn(plan.assignValues[index])
+	if err != nil {
+		return "", err
+	}
+	statement.args = append(statement.args, args...)
+	return sqlValue, nil
+}
+
 // Insert will run this query plan as an INSERT statement.
 func (plan *QueryPlan) Insert() error {
 	if len(plan.Errors) > 0 {
 		return plan.Errors[0]
 	}
 	statement := &Statement{
-		args: make([]interface{}, 0, len(plan.assignArgs)),
+		args: make([]interface{}, 0, len(plan.assignValues)),
 	}
 	statement.query.WriteString("INSERT INTO ")
 	statement.query.WriteString(plan.dbMap.Dialect.QuotedTableForQuery(plan.table.SchemaName, plan.table.TableName))
@@ -393,15 +599,19 @@ func (plan *QueryPlan) Insert() error {
 		statement.query.WriteString(col)
 	}
 	statement.query.WriteString(") VALUES (")
-	for i, bindVar := range plan.assignBindVars {
+	for i := range plan.assignValues {
 		if i > 0 {
 			statement.query.WriteString(", ")
 		}
-		statement.query.WriteString(bindVar)
-		statement.args = append(statement.args, plan.assignArgs[i])
+		value, err := plan.assignValue(statement, i)
+		if err != nil {
+			return err
+		}
+		statement.query.WriteString(value)
 	}
 	statement.query.WriteString(")")
-	_, err := plan.executor.Exec(statement.query.String(), statement.args...)
+	bindVars := plan.bindVars(statement)
+	_, err := plan.executor.Exec(statement.Query(bindVars...), statement.args...)
 	return err
 }
 
@@ -411,7 +621,7 @@ func (plan *QueryPlan) Update() (int64, error) {
 		return -1, plan.Errors[0]
 	}
 	statement := &Statement{
-		args: plan.assignArgs,
+		args: make([]interface{}, 0, len(plan.assignValues)),
 	}
 	statement.query.WriteString("UPDATE ")
 	statement.query.WriteString(plan.dbMap.Dialect.QuotedTableForQuery(plan.table.SchemaName, plan.table.TableName))
@@ -422,7 +632,11 @@ func (plan *QueryPlan) Update() (int64, error) {
 		}
 		statement.query.WriteString(col)
 		statement.query.WriteString("=")
-		statement.query.WriteString(BindVarPlaceholder)
+		value, err := plan.assignValue(statement, i)
+		if err != nil {
+			return -1, err
+		}
+		statement.query.WriteString(value)
 	}
 	if err := plan.addWhereClause(statement); err != nil {
 		return -1, err
@@ -552,8 +766,7 @@ func (plan *AssignQueryPlan) Assign(fieldPtr interface{}, value interface{}) int
 		return plan
 	}
 	plan.assignCols = append(plan.assignCols, column)
-	plan.assignBindVars = append(plan.assignBindVars, plan.dbMap.Dialect.BindVar(len(plan.assignBindVars)))
-	plan.assignArgs = append(plan.assignArgs, value)
+	plan.assignValues = append(plan.assignValues, value)
 	return plan
 }
 
@@ -2,10 +2,12 @@ package plans_test
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
@@ -15,6 +17,7 @@ import (
 	"github.com/go-gorp/gorp"
 	"github.com/nelsam/gorq"
 	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/extensions/sqliteregexp"
 	"github.com/nelsam/gorq/filters"
 	"github.com/nelsam/gorq/interfaces"
 	"github.com/nelsam/gorq/plans"
@@ -61,6 +64,7 @@ type OverriddenInvoice struct {
 	Id           string
 	TransientId  string `db:"-"`
 	unexportedId string `db:"-"`
+	MemoLength   int64  `db:"-"`
 }
 
 var testInvoices = []OverriddenInvoice{
@@ -305,7 +309,8 @@ func TestQueryLanguageMySql(t *testing.T) {
 
 func TestQueryLanguageSqlite(t *testing.T) {
 	dialect := gorp.SqliteDialect{}
-	connection, err := sql.Open("sqlite3", sqliteURL)
+	// The regexp driver is only needed for REGEXP support.
+	connection, err := sql.Open(sqliteregexp.DriverName, sqliteURL)
 	if err != nil {
 		t.Errorf("Could not connect to sqlite: %s", err)
 		return
@@ -413,6 +418,217 @@ func (suite *QueryLanguageTestSuite) TestQueryLanguage_Update() {
 	}
 }
 
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_UpdateExpressions() {
+	count, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Assign(&suite.Ref.Created, gorq.Add(gorq.Mul(&suite.Ref.Created, 10), 1)).
+		Assign(&suite.Ref.Updated, &suite.Ref.PersonId).
+		Assign(&suite.Ref.Memo, gorq.When(filters.True(&suite.Ref.IsPaid)).Then("paid").Else(gorq.Lower("UNPAID"))).
+		Where().
+		In(&suite.Ref.Id, "1", "4").
+		Update()
+	if !suite.NoError(err) {
+		return
+	}
+	suite.Equal(int64(2), count)
+
+	results, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		In(&suite.Ref.Id, "1", "4").
+		OrderBy(&suite.Ref.Id, "ASC").
+		Select()
+	if suite.NoError(err) && suite.Equal(2, len(results)) {
+		first, second := results[0].(*OverriddenInvoice), results[1].(*OverriddenInvoice)
+		suite.Equal(int64(11), first.Created)
+		suite.Equal(int64(1), first.Updated)
+		suite.Equal("unpaid", first.Memo)
+		suite.Equal(int64(21), second.Created)
+		suite.Equal(int64(1), second.Updated)
+		suite.Equal("paid", second.Memo)
+	}
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SimpleCase() {
+	count, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Assign(&suite.Ref.Memo, gorq.CaseOf(&suite.Ref.Created).When(1).Then("one").Else(gorq.Concat(&suite.Ref.Memo, "!"))).
+		Assign(&suite.Ref.Updated, gorq.CaseOf(&suite.Ref.PersonId).When(2).Then(20).Else(&suite.Ref.Updated)).
+		Where().
+		Equal(gorq.CaseOf(&suite.Ref.IsPaid).When(true).Then("paid").Else("unpaid"), "unpaid").
+		In(&suite.Ref.Id, "1", "2", "4").
+		Update()
+	if !suite.NoError(err) {
+		return
+	}
+	suite.Equal(int64(2), count)
+
+	results, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		In(&suite.Ref.Id, "1", "2", "4").
+		OrderBy(&suite.Ref.Id, "ASC").
+		Select()
+	if suite.NoError(err) && suite.Equal(3, len(results)) {
+		first, second, third := results[0].(*OverriddenInvoice), results[1].(*OverriddenInvoice), results[2].(*OverriddenInvoice)
+		suite.Equal("one", first.Memo)
+		suite.Equal(int64(1), first.Updated)
+		suite.Equal("another_test_memo!", second.Memo)
+		suite.Equal(int64(20), second.Updated)
+		suite.Equal("another_test_memo", third.Memo)
+		suite.Equal(int64(1), third.Updated)
+	}
+
+	counts, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		CountBy(gorq.CaseOf(&suite.Ref.PersonId).When(1).Then("one").Else("many"))
+	if suite.NoError(err) {
+		suite.Equal(map[interface{}]int64{"one": 4, "many": 1}, counts)
+	}
+}
+
+type expressionResult struct {
+	Label    string
+	Length   int64
+	Prefix   string
+	Bounded  int64
+	Remains  int64
+	Fallback string
+	Rounded  float64
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_Expressions() {
+	result := new(expressionResult)
+	var results []expressionResult
+	err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(gorq.Upper(gorq.Trim(&suite.Ref.Memo)), "TEST_MEMO").
+		Greater(gorq.Mod(&suite.Ref.Updated, 2), 0).
+		OrderBy(gorq.Concat(&suite.Ref.Memo, "-", &suite.Ref.Id), "DESC").
+		SelectAs(gorq.Concat(&suite.Ref.Memo, "-", &suite.Ref.Id), &result.Label).
+		SelectAs(gorq.Length(&suite.Ref.Memo), &result.Length).
+		SelectAs(gorq.Substring(&suite.Ref.Memo, 1, 4), &result.Prefix).
+		SelectAs(gorq.Least(gorq.Greatest(&suite.Ref.Created, 5), 7), &result.Bounded).
+		SelectAs(gorq.Abs(gorq.Sub(&suite.Ref.Created, 3)), &result.Remains).
+		SelectAs(gorq.Coalesce(gorq.NullIf(&suite.Ref.Memo, "test_memo"), "fallback"), &result.Fallback).
+		SelectAs(gorq.Round(gorq.Div(&suite.Ref.Updated, 7.0), 2), &result.Rounded).
+		SelectInto(&results, result)
+	if suite.NoError(err) && suite.Equal(3, len(results)) {
+		suite.Equal("test_memo-5", results[0].Label)
+		suite.Equal(int64(9), results[0].Length)
+		suite.Equal("test", results[0].Prefix)
+		suite.Equal(int64(5), results[0].Bounded)
+		suite.Equal(int64(2), results[0].Remains)
+		suite.Equal("fallback", results[0].Fallback)
+		suite.Equal(0.43, results[0].Rounded)
+	}
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_FuncAndCast() {
+	var memos []string
+	err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(gorq.Cast(&suite.Ref.Id, "integer"), 3).
+		Pluck(gorq.Func("replace", &suite.Ref.Memo, "_", " "), &memos)
+	if suite.NoError(err) {
+		suite.Equal([]string{"test memo"}, memos)
+	}
+
+	_, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(gorq.Func("lower(memo); --", &suite.Ref.Memo), "test_memo").
+		Select()
+	suite.Error(err)
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_Dates() {
+	if _, ok := suite.Map.Dialect.(dialects.SqliteDialect); !ok {
+		suite.T().Skip("Date values are only checked against SQLite's text format")
+	}
+	date := "2016-03-17 10:20:30"
+	results, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "1").
+		Less(gorq.SubInterval(gorq.Now(), 1, "day"), gorq.Now()).
+		SelectAs(gorq.DateTrunc("month", date), "month").
+		SelectAs(gorq.DateTrunc("week", date), "week").
+		SelectAs(gorq.Extract("doy", date), "doy").
+		SelectAs(gorq.Extract("dow", date), "dow").
+		SelectAs(gorq.AddInterval(date, &suite.Ref.Created, "week"), "later").
+		SelectMaps()
+	if suite.NoError(err) && suite.Equal(1, len(results)) {
+		suite.Equal("2016-03-01 00:00:00", results[0]["month"])
+		suite.Equal("2016-03-14 00:00:00", results[0]["week"])
+		suite.Equal(int64(77), results[0]["doy"])
+		suite.Equal(int64(4), results[0]["dow"])
+		suite.Equal("2016-03-24 10:20:30", results[0]["later"])
+	}
+}
+
+type Metric struct {
+	Id        int64
+	CreatedAt time.Time
+	Bytes     int64
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_TimeBuckets() {
+	// Some of the tables on suite.Map can't be created, so only the
+	// Metric table is created here.
+	table := suite.Map.AddTable(Metric{}).SetKeys(true, "Id")
+	if _, err := suite.Map.Exec(table.SqlForCreate(true)); !suite.NoError(err) {
+		return
+	}
+	defer suite.Map.DropTable(Metric{})
+
+	start := time.Date(2016, 3, 17, 10, 0, 0, 0, time.UTC)
+	for _, metric := range []Metric{
+		{CreatedAt: start.Add(5 * time.Minute), Bytes: 10},
+		{CreatedAt: start.Add(40 * time.Minute), Bytes: 20},
+		{CreatedAt: start.Add(135 * time.Minute), Bytes: 5},
+		{CreatedAt: start.Add(-time.Hour), Bytes: 100},
+	} {
+		if !suite.NoError(suite.Map.Insert(&metric)) {
+			return
+		}
+	}
+	ref := new(Metric)
+	buckets, err := plans.Query(suite.Map, suite.Map, ref).
+		Where().
+		GreaterOrEqual(&ref.CreatedAt, start).
+		TimeBuckets(&ref.CreatedAt, "hour").
+		Count()
+	if suite.NoError(err) && suite.Equal(2, len(buckets)) {
+		suite.True(start.Equal(buckets[0].Start))
+		suite.Equal(float64(2), buckets[0].Value)
+		suite.True(start.Add(2 * time.Hour).Equal(buckets[1].Start))
+		suite.Equal(float64(1), buckets[1].Value)
+	}
+
+	buckets, err = plans.Query(suite.Map, suite.Map, ref).
+		TimeBuckets(&ref.CreatedAt, "hour").
+		Fill(start.Add(30*time.Minute), start.Add(3*time.Hour)).
+		Sum(&ref.Bytes)
+	if suite.NoError(err) && suite.Equal(3, len(buckets)) {
+		for i, expected := range []float64{30, 0, 5} {
+			suite.True(start.Add(time.Duration(i) * time.Hour).Equal(buckets[i].Start))
+			suite.Equal(expected, buckets[i].Value)
+		}
+	}
+
+	_, err = plans.Query(suite.Map, suite.Map, ref).
+		TimeBuckets(&ref.CreatedAt, "fortnight").
+		Count()
+	suite.Error(err)
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_Raw() {
+	var ids []string
+	err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Filter(filters.Raw("? + ? = ?", &suite.Ref.Created, &suite.Ref.Updated, 4)).
+		OrderBy(gorq.Raw("? - ?", &suite.Ref.Created, &suite.Ref.Updated), gorq.Desc).
+		OrderBy(&suite.Ref.Id, gorq.Asc).
+		Pluck(&suite.Ref.Id, &ids)
+	if suite.NoError(err) {
+		suite.Equal([]string{"2", "3", "5"}, ids)
+	}
+}
+
 func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectSimple() {
 	invTest, err := plans.Query(suite.Map, suite.Map, suite.Ref).Select()
 	if suite.NoError(err) {
@@ -420,6 +636,368 @@ func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectSimple() {
 	}
 }
 
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_Iterate() {
+	rows, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		OrderBy(&suite.Ref.Id, "ASC").
+		Iterate()
+	if !suite.NoError(err) {
+		suite.T().FailNow()
+	}
+	defer rows.Close()
+
+	suite.Error(rows.Scan(new(Invoice)), "Scan should fail for types other than the reference type")
+	var ids []string
+	for rows.Next() {
+		inv := new(OverriddenInvoice)
+		if !suite.NoError(rows.Scan(inv)) {
+			return
+		}
+		suite.NotEqual("", inv.Memo)
+		ids = append(ids, inv.Id)
+	}
+	suite.NoError(rows.Err())
+	suite.Equal([]string{"1", "2", "3", "4", "5"}, ids)
+}
+
+type EmbeddedDetails struct {
+	Note string
+}
+
+type Label string
+
+type Embedding struct {
+	Id int64
+	Label
+	*EmbeddedDetails `db:"-"`
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_IterateEmbedded() {
+	// Some of the tables on suite.Map can't be created, so only the
+	// Embedding table is created here.
+	table := suite.Map.AddTable(Embedding{}).SetKeys(true, "Id")
+	if _, err := suite.Map.Exec(table.SqlForCreate(true)); !suite.NoError(err) {
+		return
+	}
+	defer suite.Map.DropTable(Embedding{})
+	if !suite.NoError(suite.Map.Insert(&Embedding{Label: "embedded"})) {
+		return
+	}
+
+	ref := new(Embedding)
+	rows, err := plans.Query(suite.Map, suite.Map, ref).Iterate()
+	if !suite.NoError(err) {
+		return
+	}
+	defer rows.Close()
+	var labels []Label
+	for rows.Next() {
+		row := new(Embedding)
+		if !suite.NoError(rows.Scan(row)) {
+			return
+		}
+		suite.Nil(row.EmbeddedDetails)
+		labels = append(labels, row.Label)
+	}
+	suite.NoError(rows.Err())
+	suite.Equal([]Label{"embedded"}, labels)
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_First() {
+	first, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		OrderBy(&suite.Ref.Id, "DESC").
+		First()
+	if suite.NoError(err) {
+		suite.Equal("5", first.(*OverriddenInvoice).Id)
+	}
+
+	_, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "nonexistent").
+		First()
+	suite.Equal(plans.ErrNoRows, err)
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_One() {
+	one, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "2").
+		One()
+	if suite.NoError(err) {
+		suite.Equal("another_test_memo", one.(*OverriddenInvoice).Memo)
+	}
+
+	_, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Memo, "test_memo").
+		One()
+	suite.Equal(plans.ErrMultipleRows, err)
+
+	_, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "nonexistent").
+		One()
+	suite.Equal(plans.ErrNoRows, err)
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_Exists() {
+	exists, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		True(&suite.Ref.IsPaid).
+		Exists()
+	if suite.NoError(err) {
+		suite.True(exists)
+	}
+
+	exists, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "nonexistent").
+		Exists()
+	if suite.NoError(err) {
+		suite.False(exists)
+	}
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_Pluck() {
+	var ids []string
+	err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Memo, "another_test_memo").
+		OrderBy(&suite.Ref.Id, "ASC").
+		Pluck(&suite.Ref.Id, &ids)
+	if suite.NoError(err) {
+		suite.Equal([]string{"2", "4"}, ids)
+	}
+
+	var memos []sql.NullString
+	err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "1").
+		Pluck(gorq.Lower(&suite.Ref.Memo), &memos)
+	if suite.NoError(err) && suite.Equal(1, len(memos)) {
+		suite.Equal("test_memo", memos[0].String)
+	}
+
+	var badIds []int64
+	err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Pluck(&suite.Ref.Id, &badIds)
+	suite.Error(err, "Pluck should check the target type against the field type")
+}
+
+type invoiceSummary struct {
+	InvoiceId string
+	Memo      string `db:"lower_memo"`
+	Paid      bool
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectInto() {
+	result := new(invoiceSummary)
+	var results []invoiceSummary
+	err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.PersonId, 1).
+		SelectAs(&suite.Ref.Id, &result.InvoiceId).
+		SelectAs(gorq.Lower(&suite.Ref.Memo), "lower_memo").
+		SelectAs(&suite.Ref.IsPaid, &result.Paid).
+		OrderBy(&suite.Ref.Id, "ASC").
+		SelectInto(&results, result)
+	if suite.NoError(err) && suite.Equal(4, len(results)) {
+		suite.Equal(invoiceSummary{InvoiceId: "1", Memo: "test_memo"}, results[0])
+		suite.Equal(invoiceSummary{InvoiceId: "4", Memo: "another_test_memo", Paid: true}, results[2])
+	}
+
+	var ptrResults []*invoiceSummary
+	err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "2").
+		SelectAs(&suite.Ref.Id, "InvoiceId").
+		SelectInto(&ptrResults, nil)
+	if suite.NoError(err) && suite.Equal(1, len(ptrResults)) {
+		suite.Equal("2", ptrResults[0].InvoiceId)
+	}
+
+	err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		SelectAs(&suite.Ref.Id, new(string)).
+		SelectInto(&results, result)
+	suite.Error(err, "SelectInto should fail for pointers that aren't fields on the result reference")
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectMaps() {
+	rows, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "2").
+		SelectAs(&suite.Ref.Id, "id").
+		SelectAs(gorq.Lower(&suite.Ref.Memo), "memo").
+		SelectMaps()
+	if suite.NoError(err) && suite.Equal(1, len(rows)) {
+		suite.Equal(2, len(rows[0]))
+		// Drivers may return text as either string or []byte.
+		suite.Equal("2", fmt.Sprintf("%s", rows[0]["id"]))
+		suite.Equal("another_test_memo", fmt.Sprintf("%s", rows[0]["memo"]))
+	}
+
+	rows, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "1").
+		SelectMaps()
+	if suite.NoError(err) && suite.Equal(1, len(rows)) {
+		suite.Contains(rows[0], "Memo")
+		suite.Contains(rows[0], "Id")
+	}
+
+	_, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		SelectAs(&suite.Ref.Id, &suite.Ref.Id).
+		SelectMaps()
+	suite.Error(err, "SelectMaps should require string aliases")
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectExpr() {
+	memoLength := gorq.When(filters.Equal(&suite.Ref.Memo, "test_memo")).Then(9).Else(17)
+	results, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		In(&suite.Ref.Id, "1", "2").
+		OrderBy(&suite.Ref.Id, "ASC").
+		SelectExpr(memoLength, &suite.Ref.MemoLength).
+		Select()
+	if suite.NoError(err) && suite.Equal(2, len(results)) {
+		first := results[0].(*OverriddenInvoice)
+		suite.Equal("1", first.Id)
+		suite.Equal("test_memo", first.Memo)
+		suite.Equal(int64(9), first.MemoLength)
+		suite.Equal(int64(17), results[1].(*OverriddenInvoice).MemoLength)
+	}
+
+	var invoices []OverriddenInvoice
+	err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Id, "2").
+		SelectExpr(memoLength, &suite.Ref.MemoLength).
+		SelectToTarget(&invoices)
+	if suite.NoError(err) && suite.Equal(1, len(invoices)) {
+		suite.Equal(int64(17), invoices[0].MemoLength)
+	}
+
+	q := plans.Query(suite.Map, suite.Map, suite.Ref).
+		SelectExpr(memoLength, &suite.Ref.Memo)
+	suite.NotEqual(0, len(q.(*plans.QueryPlan).Errors),
+		"SelectExpr should only accept transient fields")
+}
+
+func (suite *QueryLanguageTestSuite) keysetIds(results []interface{}) []string {
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.(*OverriddenInvoice).Id)
+	}
+	return ids
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_KeysetPage() {
+	query := func() interfaces.SelectQuery {
+		return plans.Query(suite.Map, suite.Map, suite.Ref).
+			OrderBy(&suite.Ref.Created, "DESC").
+			OrderBy(&suite.Ref.Id, "ASC")
+	}
+	var pages [][]string
+	cursor := ""
+	for page := 0; page == 0 || cursor != ""; page++ {
+		var after interface{}
+		if cursor != "" {
+			after = cursor
+		}
+		results, next, err := query().KeysetPage(nil, after, 2)
+		if !suite.NoError(err) || page > len(testInvoices) {
+			return
+		}
+		pages = append(pages, suite.keysetIds(results))
+		cursor = next
+	}
+	suite.Equal([][]string{{"2", "4"}, {"1", "3"}, {"5"}}, pages)
+
+	results, cursor, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		OrderBy(&suite.Ref.Id, "DESC").
+		KeysetPage([]interface{}{&suite.Ref.Created, &suite.Ref.Id}, []interface{}{int64(1), "5"}, 10)
+	if suite.NoError(err) {
+		suite.Equal([]string{"3", "1", "4", "2"}, suite.keysetIds(results))
+		suite.Equal("", cursor)
+	}
+
+	// Keys that all sort in the same direction use a row value
+	// comparison.
+	results, _, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		OrderBy(&suite.Ref.Created, "ASC").
+		OrderBy(&suite.Ref.Id, "ASC").
+		KeysetPage(nil, []interface{}{int64(1), "3"}, 10)
+	if suite.NoError(err) {
+		suite.Equal([]string{"5", "2", "4"}, suite.keysetIds(results))
+	}
+
+	_, _, err = query().KeysetPage(nil, []interface{}{int64(1)}, 2)
+	suite.Error(err, "KeysetPage should require one after value per key")
+	_, _, err = query().KeysetPage(nil, "not a cursor", 2)
+	suite.Error(err, "KeysetPage should reject invalid cursors")
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_Paginate() {
+	query := func() interfaces.SelectQuery {
+		return plans.Query(suite.Map, suite.Map, suite.Ref).
+			Where().
+			Equal(&suite.Ref.PersonId, 1).
+			OrderBy(&suite.Ref.Id, "ASC").
+			Limit(1).
+			Offset(1)
+	}
+	results, total, err := query().Paginate(2, 3)
+	if suite.NoError(err) {
+		suite.Equal(int64(4), total)
+		suite.Equal([]string{"5"}, suite.keysetIds(results))
+	}
+
+	results, total, err = query().Paginate(3, 3)
+	if suite.NoError(err) {
+		suite.Equal(int64(4), total, "Empty pages should still load the total")
+		suite.Equal(0, len(results))
+	}
+
+	_, total, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		GroupBy(&suite.Ref.Memo).
+		Paginate(1, 1)
+	if suite.NoError(err) {
+		suite.Equal(int64(2), total, "Grouped queries should be counted by group")
+	}
+	_, total, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		GroupBy(&suite.Ref.Memo).
+		Paginate(3, 1)
+	if suite.NoError(err) {
+		suite.Equal(int64(2), total, "Grouped queries should be counted by group")
+	}
+
+	_, _, err = query().Paginate(0, 3)
+	suite.Error(err)
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_CountBy() {
+	counts, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.PersonId, 1).
+		OrderBy(&suite.Ref.Id, "ASC").
+		Limit(1).
+		CountBy(&suite.Ref.Memo)
+	if suite.NoError(err) {
+		suite.Equal(map[string]int64{"test_memo": 3, "another_test_memo": 1}, counts)
+	}
+
+	counts, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		CountBy(&suite.Ref.IsPaid)
+	if suite.NoError(err) {
+		suite.Equal(map[bool]int64{false: 4, true: 1}, counts)
+	}
+
+	counts, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		CountBy(gorq.Lower(&suite.Ref.Memo))
+	if suite.NoError(err) {
+		suite.Equal(map[interface{}]int64{"test_memo": 3, "another_test_memo": 2}, counts)
+	}
+}
+
 func (suite *QueryLanguageTestSuite) TestQueryLanguage_CountSimple() {
 	count, err := plans.Query(suite.Map, suite.Map, suite.Ref).Count()
 	if suite.NoError(err) {
@@ -471,6 +1049,46 @@ func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectLike() {
 	}
 }
 
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectEscapedLike() {
+	expected := suite.expectedLength(func(inv OverriddenInvoice) bool {
+		return strings.HasPrefix(inv.Memo, "test_")
+	})
+
+	count, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where(filters.IStartsWith(&suite.Ref.Memo, "TEST_")).
+		Count()
+	if suite.NoError(err) {
+		suite.Equal(expected, int(count))
+	}
+
+	count, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where(filters.StartsWith(&suite.Ref.Memo, "te_t")).
+		Count()
+	if suite.NoError(err) {
+		suite.Equal(0, int(count), "Wildcards should be escaped in StartsWith")
+	}
+
+	count, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where(filters.Contains(&suite.Ref.Memo, "_test_"), filters.EndsWith(&suite.Ref.Memo, "memo")).
+		Count()
+	if suite.NoError(err) {
+		suite.Equal(len(testInvoices)-expected, int(count))
+	}
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectSearch() {
+	expected := suite.expectedLength(func(inv OverriddenInvoice) bool {
+		return strings.Contains(inv.Memo, "another") && inv.Id == "4"
+	})
+
+	count, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where(filters.Search("ANOTHER 4", &suite.Ref.Memo, &suite.Ref.Id)).
+		Count()
+	if suite.NoError(err) {
+		suite.Equal(expected, int(count))
+	}
+}
+
 func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectEqual() {
 	match := "test_memo"
 	expected := suite.expectedLength(func(inv OverriddenInvoice) bool {
@@ -486,6 +1104,21 @@ func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectEqual() {
 	}
 }
 
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectEqualPointer() {
+	match := "test_memo"
+	expected := suite.expectedLength(func(inv OverriddenInvoice) bool {
+		return inv.Memo == match
+	})
+
+	invTest, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where().
+		Equal(&suite.Ref.Memo, &match).
+		Select()
+	if suite.NoError(err) {
+		suite.Equal(expected, len(invTest))
+	}
+}
+
 func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectNotEqual() {
 	match := "test_memo"
 	expected := suite.expectedLength(func(inv OverriddenInvoice) bool {
@@ -501,6 +1134,47 @@ func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectNotEqual() {
 	}
 }
 
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectIsDistinctFrom() {
+	match := "test_memo"
+	expected := suite.expectedLength(func(inv OverriddenInvoice) bool {
+		return inv.Memo != match
+	})
+
+	invTest, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where(filters.IsDistinctFrom(&suite.Ref.Memo, match)).
+		Select()
+	if suite.NoError(err) {
+		suite.Equal(expected, len(invTest))
+	}
+
+	count, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where(filters.IsNotDistinctFrom(&suite.Ref.Memo, nil)).
+		Count()
+	if suite.NoError(err) {
+		suite.Equal(0, int(count))
+	}
+}
+
+func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectRegexp() {
+	expected := suite.expectedLength(func(inv OverriddenInvoice) bool {
+		return strings.HasPrefix(inv.Memo, "another")
+	})
+
+	invTest, err := plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where(filters.Regexp(&suite.Ref.Memo, "^an.ther_")).
+		Select()
+	if suite.NoError(err) {
+		suite.Equal(expected, len(invTest))
+	}
+
+	invTest, err = plans.Query(suite.Map, suite.Map, suite.Ref).
+		Where(filters.NotIRegexp(&suite.Ref.Memo, "^AN.THER_")).
+		Select()
+	if suite.NoError(err) {
+		suite.Equal(len(testInvoices)-expected, len(invTest))
+	}
+}
+
 func (suite *QueryLanguageTestSuite) TestQueryLanguage_SelectLess() {
 	expectedCount := suite.expectedLength(func(inv OverriddenInvoice) bool {
 		return inv.Updated < 2
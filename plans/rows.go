@@ -0,0 +1,306 @@
+package plans
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq/interfaces"
+)
+
+// selectColumns returns the columns that will be selected, in order,
+// when selecting rows of the reference type.
+func (plan *QueryPlan) selectColumns() []*gorp.ColumnMap {
+	columns := make([]*gorp.ColumnMap, 0, len(plan.table.Columns))
+	for _, col := range plan.table.Columns {
+		if !col.Transient {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// walkFields calls fn for each exported field in structType, along
+// with the field's index within structType.  Like gorp's
+// readStructColumns, it recurses into anonymous struct fields (but not
+// pointers to structs), passing their fields to fn instead of the
+// anonymous field itself.
+func walkFields(structType reflect.Type, fn func(field reflect.StructField, index []int)) {
+	var walk func(reflect.Type, []int)
+	walk = func(t reflect.Type, parent []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			index := append(append([]int{}, parent...), i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				walk(field.Type, index)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			fn(field, index)
+		}
+	}
+	walk(structType, nil)
+}
+
+// colMapOrNil returns the column in table for the field named
+// fieldName, or nil if the field has no column.  gorp's ColMap panics
+// in that case.
+func colMapOrNil(table *gorp.TableMap, fieldName string) (col *gorp.ColumnMap) {
+	defer func() {
+		if r := recover(); r != nil {
+			col = nil
+		}
+	}()
+	return table.ColMap(fieldName)
+}
+
+// columnFieldIndexes maps the columns in table to the index of their
+// field within structType.  Embedded structs are searched the same
+// way that gorp searches them when mapping columns, and fields that
+// are closest to the surface take precedence over fields of the same
+// name in embedded structs.
+func columnFieldIndexes(structType reflect.Type, table *gorp.TableMap) map[*gorp.ColumnMap][]int {
+	indexes := make(map[*gorp.ColumnMap][]int)
+	walkFields(structType, func(field reflect.StructField, index []int) {
+		col := colMapOrNil(table, field.Name)
+		if col == nil {
+			return
+		}
+		if existing, ok := indexes[col]; !ok || len(index) < len(existing) {
+			indexes[col] = index
+		}
+	})
+	return indexes
+}
+
+// Rows is an iterator over the results of a select statement,
+// implementing interfaces.Rows.
+type Rows struct {
+	rows     *sql.Rows
+	executor gorp.SqlExecutor
+	conv     gorp.TypeConverter
+	rowType  reflect.Type
+	fields   [][]int
+
+	// extra holds destinations for any columns selected after the
+	// columns in fields, e.g. window function results.
+	extra []interface{}
+}
+
+// Next implements interfaces.Rows.Next.
+func (rows *Rows) Next() bool {
+	return rows.rows.Next()
+}
+
+// Err implements interfaces.Rows.Err.
+func (rows *Rows) Err() error {
+	return rows.rows.Err()
+}
+
+// Close implements interfaces.Rows.Close.
+func (rows *Rows) Close() error {
+	return rows.rows.Close()
+}
+
+// Scan implements interfaces.Rows.Scan.
+func (rows *Rows) Scan(target interface{}) error {
+	targetVal := reflect.ValueOf(target)
+	if targetVal.Type() != reflect.PtrTo(rows.rowType) || targetVal.IsNil() {
+		return fmt.Errorf("gorp: Scan target must be a non-nil %s, not %T", reflect.PtrTo(rows.rowType), target)
+	}
+	dest := make([]interface{}, 0, len(rows.fields))
+	var custScan []gorp.CustomScanner
+	for _, index := range rows.fields {
+		fieldTarget := targetVal.Elem().FieldByIndex(index).Addr().Interface()
+		if rows.conv != nil {
+			if scanner, ok := rows.conv.FromDb(fieldTarget); ok {
+				fieldTarget = scanner.Holder
+				custScan = append(custScan, scanner)
+			}
+		}
+		dest = append(dest, fieldTarget)
+	}
+	dest = append(dest, rows.extra...)
+	if err := rows.rows.Scan(dest...); err != nil {
+		return err
+	}
+	for _, scanner := range custScan {
+		if err := scanner.Bind(); err != nil {
+			return err
+		}
+	}
+	if getter, ok := target.(gorp.HasPostGet); ok {
+		return getter.PostGet(rows.executor)
+	}
+	return nil
+}
+
+// Iterate will run this query plan as a SELECT statement, returning
+// an iterator over the resulting rows.  Unlike Select, rows are only
+// loaded into memory one at a time, as Scan is called.
+func (plan *QueryPlan) Iterate() (interfaces.Rows, error) {
+	statement, err := plan.SelectStatement()
+	if err != nil {
+		return nil, err
+	}
+	bindVars := plan.bindVars(statement)
+	rows, err := plan.queryRows(plan.referenceTarget(), statement.Query(bindVars...), statement.args...)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// queryRows runs query, returning Rows that scan the selected columns
+// (and any expressions passed to SelectExpr) into values of the same
+// type as target.
+func (plan *QueryPlan) queryRows(target reflect.Value, query string, args ...interface{}) (*Rows, error) {
+	rowType := target.Type().Elem()
+	indexes := columnFieldIndexes(rowType, plan.table)
+	columns := plan.selectColumns()
+	fields := make([][]int, 0, len(columns)+len(plan.selectExprs))
+	for _, col := range columns {
+		index, ok := indexes[col]
+		if !ok {
+			return nil, fmt.Errorf("gorp: Cannot find a field in %s for the column %s", rowType, col.ColumnName)
+		}
+		fields = append(fields, index)
+	}
+	for _, expr := range plan.selectExprs {
+		index, err := resultFieldIndex(rowType, target.Elem(), expr.as)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, index)
+	}
+
+	rows, err := plan.executor.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{
+		rows:     rows,
+		executor: plan.executor,
+		conv:     plan.dbMap.TypeConverter,
+		rowType:  rowType,
+		fields:   fields,
+	}, nil
+}
+
+// scanSelect runs query and scans the results the same way that
+// gorp.SqlExecutor.Select would: if target is a pointer to a slice,
+// results are appended to it; otherwise, they're returned as a slice
+// of pointers to new values of target's type.
+func (plan *QueryPlan) scanSelect(target interface{}, query string, args ...interface{}) ([]interface{}, error) {
+	ref := plan.referenceTarget()
+	var sliceVal reflect.Value
+	targetVal := reflect.ValueOf(target)
+	if targetVal.Kind() == reflect.Ptr && targetVal.Elem().Kind() == reflect.Slice {
+		sliceVal = targetVal.Elem()
+		elemType := sliceVal.Type().Elem()
+		if elemType != ref.Type() && elemType != ref.Type().Elem() {
+			return nil, fmt.Errorf("gorp: Cannot select values of type %s into a %s", ref.Type().Elem(), sliceVal.Type())
+		}
+	}
+	rows, err := plan.queryRows(ref, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []interface{}
+	for rows.Next() {
+		result := reflect.New(rows.rowType)
+		if err := rows.Scan(result.Interface()); err != nil {
+			return nil, err
+		}
+		if !sliceVal.IsValid() {
+			results = append(results, result.Interface())
+			continue
+		}
+		if sliceVal.Type().Elem().Kind() != reflect.Ptr {
+			result = result.Elem()
+		}
+		sliceVal.Set(reflect.Append(sliceVal, result))
+	}
+	return results, rows.Err()
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// checkPluckType returns an error if values of fieldPtrOrWrapper
+// can't be scanned into elemType.  Only field pointers can be
+// checked; for anything else, the database will have the final say.
+func (plan *QueryPlan) checkPluckType(fieldPtrOrWrapper interface{}, elemType reflect.Type) error {
+	if _, err := plan.colMap.fieldMapForPointer(fieldPtrOrWrapper); err != nil {
+		return nil
+	}
+	fieldType := reflect.TypeOf(fieldPtrOrWrapper).Elem()
+	if elemType == fieldType || elemType == reflect.PtrTo(fieldType) || reflect.PtrTo(elemType).Implements(scannerType) {
+		return nil
+	}
+	return fmt.Errorf("gorp: Cannot pluck values of field type %s into a slice of %s", fieldType, elemType)
+}
+
+// Pluck will run this query plan as a SELECT statement for only the
+// column (or expression) represented by fieldPtrOrWrapper, and
+// append the results to target, which must be a pointer to a slice.
+func (plan *QueryPlan) Pluck(fieldPtrOrWrapper interface{}, target interface{}) error {
+	if len(plan.Errors) > 0 {
+		return plan.Errors[0]
+	}
+	targetType := reflect.TypeOf(target)
+	if targetType.Kind() != reflect.Ptr || targetType.Elem().Kind() != reflect.Slice {
+		return errors.New("Pluck must be run with a pointer to a slice as its target")
+	}
+	sliceVal := reflect.ValueOf(target).Elem()
+	elemType := targetType.Elem().Elem()
+	if err := plan.checkPluckType(fieldPtrOrWrapper, elemType); err != nil {
+		return err
+	}
+
+	statement := new(Statement)
+	statement.query.WriteString("SELECT ")
+	args, column, err := plan.argOrColumn(fieldPtrOrWrapper)
+	if err != nil {
+		return err
+	}
+	statement.query.WriteString(column)
+	statement.args = append(statement.args, args...)
+	if err := plan.addSelectSuffix(statement); err != nil {
+		return err
+	}
+	plan.addLockingClause(statement)
+	bindVars := plan.bindVars(statement)
+	rows, err := plan.executor.Query(statement.Query(bindVars...), statement.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	conv := plan.dbMap.TypeConverter
+	for rows.Next() {
+		value := reflect.New(elemType)
+		dest := value.Interface()
+		var scanner gorp.CustomScanner
+		custom := false
+		if conv != nil {
+			scanner, custom = conv.FromDb(dest)
+			if custom {
+				dest = scanner.Holder
+			}
+		}
+		if err := rows.Scan(dest); err != nil {
+			return err
+		}
+		if custom {
+			if err := scanner.Bind(); err != nil {
+				return err
+			}
+		}
+		sliceVal.Set(reflect.Append(sliceVal, value.Elem()))
+	}
+	return rows.Err()
+}
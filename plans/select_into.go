@@ -0,0 +1,274 @@
+package plans
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nelsam/gorq/interfaces"
+)
+
+// selectAs is an expression that should be selected as a named
+// result column, for SelectInto and SelectMaps.
+type selectAs struct {
+	expr interface{}
+	as   interface{}
+}
+
+// SelectAs adds expr (a field pointer, SqlWrapper, or any other value
+// that can be used in a where clause) to the list of values that
+// SelectInto and SelectMaps will select.  The as parameter may be
+// either a string alias or a pointer to a field of the result
+// reference passed to SelectInto.
+func (plan *QueryPlan) SelectAs(expr interface{}, as interface{}) interfaces.SelectQuery {
+	switch src := as.(type) {
+	case string:
+		if src == "" {
+			plan.Errors = append(plan.Errors, errors.New("gorp: SelectAs aliases cannot be empty"))
+		}
+	default:
+		if reflect.ValueOf(as).Kind() != reflect.Ptr {
+			plan.Errors = append(plan.Errors, fmt.Errorf("gorp: SelectAs requires a string alias or a field pointer, not %T", as))
+		}
+	}
+	plan.selectAs = append(plan.selectAs, selectAs{expr: expr, as: as})
+	return plan
+}
+
+// SelectExpr adds expr to the list of values selected by Select,
+// SelectToTarget, and the other methods that return reference
+// structs, populating the transient field that fieldPtr points to.
+// This allows computed values (counts, distances, CASE results,
+// etc) to be loaded alongside the normal columns.  For example:
+//
+//     type Parent struct {
+//         Id         int64
+//         ChildCount int64 `db:"-"`
+//     }
+//     results, err := dbMap.Query(ref).
+//         LeftJoin(child).On().Equal(&child.ParentId, &ref.Id).
+//         GroupBy(&ref.Id).
+//         SelectExpr(gorq.Count(&child.Id), &ref.ChildCount).
+//         Select()
+func (plan *QueryPlan) SelectExpr(expr interface{}, fieldPtr interface{}) interfaces.SelectQuery {
+	if _, err := plan.colMap.transientFieldMapForPointer(fieldPtr); err != nil {
+		plan.Errors = append(plan.Errors, err)
+		return plan
+	}
+	plan.selectExprs = append(plan.selectExprs, selectAs{expr: expr, as: fieldPtr})
+	return plan
+}
+
+// selectAsStatement generates a select statement for the expressions
+// passed to SelectAs, using aliases as the column aliases.
+func (plan *QueryPlan) selectAsStatement(aliases []string) (*Statement, error) {
+	if len(plan.Errors) > 0 {
+		return nil, plan.Errors[0]
+	}
+	statement := new(Statement)
+	statement.query.WriteString("SELECT ")
+	for index, sel := range plan.selectAs {
+		if index != 0 {
+			statement.query.WriteString(",")
+		}
+		args, val, err := plan.argOrColumn(sel.expr)
+		if err != nil {
+			return nil, err
+		}
+		statement.query.WriteString(val)
+		statement.query.WriteString(" AS ")
+		statement.query.WriteString(plan.dbMap.Dialect.QuoteField(aliases[index]))
+		statement.args = append(statement.args, args...)
+	}
+	if err := plan.addSelectSuffix(statement); err != nil {
+		return nil, err
+	}
+	plan.addLockingClause(statement)
+	return statement, nil
+}
+
+// resultFieldIndex returns the index of the field in resultType that
+// as refers to.  Field pointers are looked up in resultRef, while
+// string aliases are matched against db tags and field names.
+func resultFieldIndex(resultType reflect.Type, resultRef reflect.Value, as interface{}) (index []int, err error) {
+	alias, isAlias := as.(string)
+	walkFields(resultType, func(field reflect.StructField, fieldIndex []int) {
+		if index != nil && len(index) <= len(fieldIndex) {
+			return
+		}
+		if !isAlias {
+			if resultRef.IsValid() && resultRef.FieldByIndex(fieldIndex).Addr().Interface() == as {
+				index = fieldIndex
+			}
+			return
+		}
+		name := strings.Split(field.Tag.Get("db"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, alias) {
+			index = fieldIndex
+		}
+	})
+	if index == nil {
+		if isAlias {
+			return nil, fmt.Errorf("gorp: Cannot find a field in %s matching the alias %s", resultType, alias)
+		}
+		return nil, fmt.Errorf("gorp: Cannot find a field in the result reference matching the pointer %v", as)
+	}
+	return index, nil
+}
+
+// SelectInto will run this query plan as a SELECT statement for the
+// values passed to SelectAs, appending a result struct for each row
+// to target, which must be a pointer to a slice of structs (or of
+// struct pointers).  If any SelectAs calls used field pointers as
+// their destination, resultRef must be the struct value that those
+// pointers point into; otherwise, it may be nil.  For example:
+//
+//     type Result struct {
+//         Name  string
+//         Total int64
+//     }
+//     result := new(Result)
+//     var results []Result
+//     err := dbMap.Query(ref).
+//         Join(order).On().Equal(&order.CustomerId, &ref.Id).
+//         SelectAs(&ref.Name, &result.Name).
+//         SelectAs(gorq.Count(&order.Id), &result.Total).
+//         GroupBy(&ref.Name).
+//         SelectInto(&results, result)
+func (plan *QueryPlan) SelectInto(target interface{}, resultRef interface{}) error {
+	if len(plan.selectAs) == 0 {
+		return errors.New("gorp: SelectInto requires at least one value passed to SelectAs")
+	}
+	targetType := reflect.TypeOf(target)
+	if targetType == nil || targetType.Kind() != reflect.Ptr || targetType.Elem().Kind() != reflect.Slice {
+		return errors.New("gorp: SelectInto must be run with a pointer to a slice as its target")
+	}
+	sliceVal := reflect.ValueOf(target).Elem()
+	elemType := sliceVal.Type().Elem()
+	rowType := elemType
+	if rowType.Kind() == reflect.Ptr {
+		rowType = rowType.Elem()
+	}
+	if rowType.Kind() != reflect.Struct {
+		return fmt.Errorf("gorp: SelectInto requires a slice of structs, not %s", sliceVal.Type())
+	}
+	var refVal reflect.Value
+	if resultRef != nil {
+		refVal = reflect.ValueOf(resultRef)
+		if refVal.Type() != reflect.PtrTo(rowType) || refVal.IsNil() {
+			return fmt.Errorf("gorp: SelectInto requires a result reference of type %s, not %T", reflect.PtrTo(rowType), resultRef)
+		}
+		refVal = refVal.Elem()
+	}
+
+	aliases := make([]string, 0, len(plan.selectAs))
+	fields := make([][]int, 0, len(plan.selectAs))
+	for _, sel := range plan.selectAs {
+		index, err := resultFieldIndex(rowType, refVal, sel.as)
+		if err != nil {
+			return err
+		}
+		alias, ok := sel.as.(string)
+		if !ok {
+			alias = rowType.FieldByIndex(index).Name
+		}
+		aliases = append(aliases, alias)
+		fields = append(fields, index)
+	}
+
+	statement, err := plan.selectAsStatement(aliases)
+	if err != nil {
+		return err
+	}
+	bindVars := plan.bindVars(statement)
+	sqlRows, err := plan.executor.Query(statement.Query(bindVars...), statement.args...)
+	if err != nil {
+		return err
+	}
+	rows := &Rows{
+		rows:     sqlRows,
+		executor: plan.executor,
+		conv:     plan.dbMap.TypeConverter,
+		rowType:  rowType,
+		fields:   fields,
+	}
+	defer rows.Close()
+	for rows.Next() {
+		result := reflect.New(rowType)
+		if err := rows.Scan(result.Interface()); err != nil {
+			return err
+		}
+		if elemType.Kind() != reflect.Ptr {
+			result = result.Elem()
+		}
+		sliceVal.Set(reflect.Append(sliceVal, result))
+	}
+	return rows.Err()
+}
+
+// SelectMaps will run this query plan as a SELECT statement,
+// returning each row as a map of column alias to value.  If SelectAs
+// has been called, only the values passed to SelectAs will be
+// selected, and all of them must use string aliases; otherwise, the
+// columns of the reference struct will be selected, keyed by column
+// name.  Values are returned as the database driver returns them.
+func (plan *QueryPlan) SelectMaps() ([]map[string]interface{}, error) {
+	var (
+		statement *Statement
+		aliases   []string
+		err       error
+	)
+	if len(plan.selectAs) == 0 {
+		for _, col := range plan.selectColumns() {
+			aliases = append(aliases, col.ColumnName)
+		}
+		ref := plan.referenceTarget()
+		for _, expr := range plan.selectExprs {
+			index, err := resultFieldIndex(ref.Type().Elem(), ref.Elem(), expr.as)
+			if err != nil {
+				return nil, err
+			}
+			aliases = append(aliases, ref.Type().Elem().FieldByIndex(index).Name)
+		}
+		statement, err = plan.SelectStatement()
+	} else {
+		for _, sel := range plan.selectAs {
+			alias, ok := sel.as.(string)
+			if !ok {
+				return nil, errors.New("gorp: SelectMaps requires string aliases for all values passed to SelectAs")
+			}
+			aliases = append(aliases, alias)
+		}
+		statement, err = plan.selectAsStatement(aliases)
+	}
+	if err != nil {
+		return nil, err
+	}
+	bindVars := plan.bindVars(statement)
+	rows, err := plan.executor.Query(statement.Query(bindVars...), statement.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(aliases))
+		dest := make([]interface{}, len(aliases))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result := make(map[string]interface{}, len(aliases))
+		for i, alias := range aliases {
+			result[alias] = values[i]
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -38,6 +38,7 @@ func (plan *QueryPlan) SelectStatement() (*Statement, error) {
 	if err := plan.addSelectSuffix(statement); err != nil {
 		return nil, err
 	}
+	plan.addLockingClause(statement)
 	return statement, nil
 }
 
@@ -47,15 +48,22 @@ func (plan *QueryPlan) addSelectColumns(statement *Statement) error {
 	if len(plan.Errors) > 0 {
 		return plan.Errors[0]
 	}
-	for index, col := range plan.table.Columns {
-		if !col.Transient {
-			if index != 0 {
-				statement.query.WriteString(",")
-			}
-			statement.query.WriteString(plan.QuotedTable())
-			statement.query.WriteString(".")
-			statement.query.WriteString(plan.dbMap.Dialect.QuoteField(col.ColumnName))
+	for index, col := range plan.selectColumns() {
+		if index != 0 {
+			statement.query.WriteString(",")
 		}
+		statement.query.WriteString(plan.QuotedTable())
+		statement.query.WriteString(".")
+		statement.query.WriteString(plan.dbMap.Dialect.QuoteField(col.ColumnName))
+	}
+	for _, expr := range plan.selectExprs {
+		args, val, err := plan.argOrColumn(expr.expr)
+		if err != nil {
+			return err
+		}
+		statement.query.WriteString(",")
+		statement.query.WriteString(val)
+		statement.args = append(statement.args, args...)
 	}
 	return nil
 }
@@ -118,13 +126,8 @@ func (plan *QueryPlan) addSelectSuffix(statement *Statement) error {
 	if err := plan.addWhereClause(statement); err != nil {
 		return err
 	}
-	for index, groupBy := range plan.groupBy {
-		if index == 0 {
-			statement.query.WriteString(" GROUP BY ")
-		} else {
-			statement.query.WriteString(", ")
-		}
-		statement.query.WriteString(groupBy)
+	if err := plan.addGroupByClause(statement); err != nil {
+		return err
 	}
 	for index, orderBy := range plan.orderBy {
 		if index == 0 {
@@ -136,8 +139,11 @@ func (plan *QueryPlan) addSelectSuffix(statement *Statement) error {
 		if err != nil {
 			return err
 		}
-		statement.query.WriteString(orderBy.OrderBy(val))
-		statement.args = append(statement.args, args...)
+		clause, uses := orderBy.OrderBy(plan.dbMap.Dialect, val)
+		statement.query.WriteString(clause)
+		for i := 0; i < uses; i++ {
+			statement.args = append(statement.args, args...)
+		}
 	}
 	// Nonstandard LIMIT clauses seem to have to come *before* the
 	// offset clause.
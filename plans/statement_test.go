@@ -0,0 +1,528 @@
+package plans_test
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/nelsam/gorq"
+	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/filters"
+	"github.com/nelsam/gorq/plans"
+	"github.com/stretchr/testify/suite"
+)
+
+// StatementTestSuite tests generated SQL for a dialect, without
+// needing a connection to a database.
+type StatementTestSuite struct {
+	suite.Suite
+	Map *gorp.DbMap
+	Ref *OverriddenInvoice
+}
+
+func runStatementSuite(t *testing.T, dialect gorp.Dialect) {
+	dbMap := new(gorp.DbMap)
+	dbMap.Dialect = dialect
+	dbMap.AddTable(OverriddenInvoice{}).SetKeys(false, "Id")
+	testSuite := new(StatementTestSuite)
+	testSuite.Map = dbMap
+	suite.Run(t, testSuite)
+}
+
+func TestStatementPostgres(t *testing.T) {
+	runStatementSuite(t, gorp.PostgresDialect{})
+}
+
+func TestStatementMySql(t *testing.T) {
+	runStatementSuite(t, gorp.MySQLDialect{"InnoDB", "UTF8"})
+}
+
+func TestStatementSqlite(t *testing.T) {
+	runStatementSuite(t, gorp.SqliteDialect{})
+}
+
+func (suite *StatementTestSuite) SetupTest() {
+	suite.Ref = new(OverriddenInvoice)
+}
+
+// query returns a new QueryPlan for suite.Ref.
+func (suite *StatementTestSuite) query() *plans.QueryPlan {
+	return plans.Query(suite.Map, suite.Map, suite.Ref).(*plans.QueryPlan)
+}
+
+// whereClause returns the portion of plan's select statement
+// starting after "WHERE ", with placeholders still in place, along
+// with the statement's arguments.
+func (suite *StatementTestSuite) whereClause(plan *plans.QueryPlan) (string, []interface{}) {
+	statement, err := plan.SelectStatement()
+	if !suite.NoError(err) {
+		suite.T().FailNow()
+	}
+	query := statement.Query()
+	const where = " WHERE "
+	for i := 0; i+len(where) <= len(query); i++ {
+		if query[i:i+len(where)] == where {
+			return query[i+len(where):], statement.Args()
+		}
+	}
+	suite.Fail("No where clause found", "Query: %s", query)
+	return "", nil
+}
+
+// column returns the quoted table.column string for col on suite.Ref.
+func (suite *StatementTestSuite) column(col string) string {
+	return suite.Map.Dialect.QuotedTableForQuery("", "OverriddenInvoice") + "." + suite.Map.Dialect.QuoteField(col)
+}
+
+func (suite *StatementTestSuite) TestStatement_EqualNil() {
+	where, args := suite.whereClause(suite.query().Where().Equal(&suite.Ref.Memo, nil).(*plans.QueryPlan))
+	suite.Equal(suite.column("Memo")+" is null", where)
+	suite.Empty(args)
+
+	where, args = suite.whereClause(suite.query().Where().NotEqual(&suite.Ref.Memo, nil).(*plans.QueryPlan))
+	suite.Equal(suite.column("Memo")+" is not null", where)
+	suite.Empty(args)
+}
+
+func (suite *StatementTestSuite) TestStatement_IsDistinctFrom() {
+	plan := suite.query()
+	var distinct, notDistinct string
+	memo := suite.column("Memo")
+	switch suite.Map.Dialect.(type) {
+	case dialects.MySQLDialect:
+		distinct, notDistinct = "not "+memo+" <=> %s", memo+" <=> %s"
+	case dialects.SqliteDialect:
+		distinct, notDistinct = memo+" IS NOT %s", memo+" IS %s"
+	default:
+		distinct, notDistinct = memo+" IS DISTINCT FROM %s", memo+" IS NOT DISTINCT FROM %s"
+	}
+
+	plan.Where(filters.IsDistinctFrom(&suite.Ref.Memo, "foo"))
+	where, args := suite.whereClause(plan)
+	suite.Equal(distinct, where)
+	suite.Equal([]interface{}{"foo"}, args)
+
+	plan = suite.query()
+	plan.Where(filters.Or(filters.IsNotDistinctFrom(&suite.Ref.Memo, nil), filters.Equal(&suite.Ref.Updated, 2)))
+	where, args = suite.whereClause(plan)
+	suite.Equal("("+notDistinct+" or "+suite.column("Updated")+"=%s)", where)
+	suite.Equal([]interface{}{nil, 2}, args)
+}
+
+func (suite *StatementTestSuite) TestStatement_Regexp() {
+	plan := suite.query()
+	memo := suite.column("Memo")
+	var match, notMatch, iMatch, notIMatch string
+	iPattern := "^test"
+	switch suite.Map.Dialect.(type) {
+	case dialects.MySQLDialect:
+		match, notMatch = "REGEXP_LIKE("+memo+", %s, %s)", "not REGEXP_LIKE("+memo+", %s, %s)"
+		iMatch, notIMatch = match, notMatch
+	case dialects.SqliteDialect:
+		match, notMatch = memo+" REGEXP %s", "not "+memo+" REGEXP %s"
+		iMatch, notIMatch = match, notMatch
+		iPattern = "(?i)^test"
+	default:
+		match, notMatch = memo+" ~ %s", memo+" !~ %s"
+		iMatch, notIMatch = memo+" ~* %s", memo+" !~* %s"
+	}
+
+	plan.Where(
+		filters.Regexp(&suite.Ref.Memo, "^test"),
+		filters.NotRegexp(&suite.Ref.Memo, "^test"),
+		filters.IRegexp(&suite.Ref.Memo, "^test"),
+		filters.NotIRegexp(&suite.Ref.Memo, "^test"),
+	)
+	where, args := suite.whereClause(plan)
+	suite.Equal("("+match+" and "+notMatch+" and "+iMatch+" and "+notIMatch+")", where)
+	if _, ok := suite.Map.Dialect.(dialects.MySQLDialect); ok {
+		suite.Equal([]interface{}{"^test", "c", "^test", "c", "^test", "i", "^test", "i"}, args)
+		return
+	}
+	suite.Equal([]interface{}{"^test", "^test", iPattern, iPattern}, args)
+}
+
+func (suite *StatementTestSuite) TestStatement_Contains() {
+	plan := suite.query()
+	memo := suite.column("Memo")
+	escape := `'\'`
+	iContains := "lower(" + memo + ") like lower(%s) ESCAPE "
+	switch suite.Map.Dialect.(type) {
+	case dialects.MySQLDialect:
+		escape = `'\\'`
+	case dialects.SqliteDialect:
+	default:
+		iContains = memo + " ilike %s ESCAPE "
+	}
+
+	plan.Where(filters.Contains(&suite.Ref.Memo, "50%"), filters.IContains(&suite.Ref.Memo, "a_b"))
+	where, args := suite.whereClause(plan)
+	suite.Equal("("+memo+" like %s ESCAPE "+escape+" and "+iContains+escape+")", where)
+	suite.Equal([]interface{}{`%50\%%`, `%a\_b%`}, args)
+}
+
+func (suite *StatementTestSuite) TestStatement_Search() {
+	plan := suite.query()
+	plan.Where(filters.Search("  ", &suite.Ref.Memo), filters.Equal(&suite.Ref.Updated, 1))
+	where, args := suite.whereClause(plan)
+	suite.Equal("(1=1 and "+suite.column("Updated")+"=%s)", where, "Empty searches should match every row")
+	suite.Equal([]interface{}{1}, args)
+
+	plan = suite.query()
+	plan.Where(filters.Not(filters.Search("", &suite.Ref.Memo)))
+	where, _ = suite.whereClause(plan)
+	suite.Equal("not 1=1", where)
+
+	plan = suite.query()
+	plan.Where(filters.Search("foo bar", &suite.Ref.Memo, &suite.Ref.Id))
+	where, args = suite.whereClause(plan)
+	suite.Equal(4, strings.Count(where, " ESCAPE "))
+	suite.Equal(1, strings.Count(where, ") and ("))
+	suite.Equal([]interface{}{"%foo%", "%foo%", "%bar%", "%bar%"}, args)
+
+	plan = suite.query()
+	plan.Where(filters.Search("foo"))
+	_, err := plan.SelectStatement()
+	suite.Error(err, "Searches without any fields should fail")
+}
+
+func (suite *StatementTestSuite) TestStatement_NonFieldPointers() {
+	memo := "test_memo"
+	created := time.Now()
+	plan := suite.query()
+	plan.Where().
+		Equal(&suite.Ref.Memo, &memo).
+		Less(&suite.Ref.Created, &created).
+		Equal(filters.Col(&suite.Ref.Updated), filters.Value(&suite.Ref.Updated))
+	where, args := suite.whereClause(plan)
+	suite.Equal("("+suite.column("Memo")+"=%s and "+suite.column("Created")+"<%s and "+suite.column("Updated")+"=%s)", where)
+	suite.Equal([]interface{}{&memo, &created, &suite.Ref.Updated}, args)
+
+	plan = suite.query()
+	plan.Where().Equal(filters.Col(&memo), "test_memo")
+	_, err := plan.SelectStatement()
+	suite.Error(err, "filters.Col should never bind its value")
+
+	plan = suite.query()
+	plan.Where().Equal(&suite.Ref.Memo, new(OverriddenInvoice))
+	_, err = plan.SelectStatement()
+	suite.Error(err, "Pointers to struct types should not be bound as values")
+
+	// Pointers to scalars are always bound, even when they point
+	// into a reference struct that isn't part of the query; only
+	// filters.Col is strict about column references.
+	otherRef := new(OverriddenInvoice)
+	plans.Query(suite.Map, suite.Map, otherRef)
+	plan = suite.query()
+	plan.Where().Equal(&suite.Ref.Memo, &otherRef.Memo)
+	where, args = suite.whereClause(plan)
+	suite.Equal(suite.column("Memo")+"=%s", where)
+	suite.Equal([]interface{}{&otherRef.Memo}, args)
+
+	plan = suite.query()
+	plan.Where().Equal(&suite.Ref.Memo, filters.Col(&otherRef.Memo))
+	_, err = plan.SelectStatement()
+	suite.IsType(&plans.FieldNotFoundError{}, err, "filters.Col should fail for fields outside of the query")
+}
+
+// groupByClause returns the portion of plan's select statement
+// starting after "GROUP BY ".
+func (suite *StatementTestSuite) groupByClause(plan *plans.QueryPlan) (string, error) {
+	statement, err := plan.SelectStatement()
+	if err != nil {
+		return "", err
+	}
+	query := statement.Query()
+	const groupBy = " GROUP BY "
+	index := strings.Index(query, groupBy)
+	if index < 0 {
+		suite.Fail("No group by clause found", "Query: %s", query)
+		return "", nil
+	}
+	return query[index+len(groupBy):], nil
+}
+
+func (suite *StatementTestSuite) TestStatement_GroupByRollup() {
+	plan := suite.query().GroupByRollup(&suite.Ref.PersonId, &suite.Ref.Memo).(*plans.QueryPlan)
+	switch suite.Map.Dialect.(type) {
+	case dialects.SqliteDialect:
+		suite.NotEmpty(plan.Errors, "SQLite does not support ROLLUP")
+	case dialects.MySQLDialect:
+		groupBy, err := suite.groupByClause(plan)
+		if suite.NoError(err) {
+			suite.Equal(suite.column("PersonId")+", "+suite.column("Memo")+" WITH ROLLUP", groupBy)
+		}
+		plan.GroupBy(&suite.Ref.IsPaid)
+		_, err = plan.SelectStatement()
+		suite.Error(err, "MySQL's WITH ROLLUP cannot be mixed with other group by fields")
+	default:
+		plan.GroupBy(&suite.Ref.IsPaid)
+		groupBy, err := suite.groupByClause(plan)
+		if suite.NoError(err) {
+			suite.Equal(suite.column("IsPaid")+", ROLLUP("+suite.column("PersonId")+", "+suite.column("Memo")+")", groupBy)
+		}
+	}
+}
+
+func (suite *StatementTestSuite) TestStatement_GroupByCubeAndGroupingSets() {
+	cube := suite.query().GroupByCube(&suite.Ref.PersonId, &suite.Ref.Memo).(*plans.QueryPlan)
+	sets := suite.query().GroupingSets(
+		[]interface{}{&suite.Ref.PersonId, &suite.Ref.Memo},
+		[]interface{}{&suite.Ref.Memo},
+		[]interface{}{},
+	).(*plans.QueryPlan)
+	switch suite.Map.Dialect.(type) {
+	case dialects.SqliteDialect, dialects.MySQLDialect:
+		suite.NotEmpty(cube.Errors)
+		suite.NotEmpty(sets.Errors)
+	default:
+		groupBy, err := suite.groupByClause(cube)
+		if suite.NoError(err) {
+			suite.Equal("CUBE("+suite.column("PersonId")+", "+suite.column("Memo")+")", groupBy)
+		}
+		groupBy, err = suite.groupByClause(sets)
+		if suite.NoError(err) {
+			suite.Equal("GROUPING SETS (("+suite.column("PersonId")+", "+suite.column("Memo")+"), ("+suite.column("Memo")+"), ())", groupBy)
+		}
+	}
+}
+
+func (suite *StatementTestSuite) TestStatement_GroupByExpression() {
+	plan := suite.query().
+		Where().
+		Equal(&suite.Ref.PersonId, 1).
+		GroupBy(gorq.Lower(&suite.Ref.Memo)).
+		GroupBy(gorq.When(filters.Equal(&suite.Ref.IsPaid, true)).Then("paid").Else("unpaid")).
+		OrderBy(gorq.When(filters.Equal(&suite.Ref.IsPaid, true)).Then(0).Else(1), "").(*plans.QueryPlan)
+	groupBy, err := suite.groupByClause(plan)
+	if suite.NoError(err) {
+		isPaid := suite.column("IsPaid") + "=%s"
+		suite.Equal("lower("+suite.column("Memo")+"), CASE WHEN "+isPaid+" THEN %s ELSE %s END"+
+			" ORDER BY CASE WHEN "+isPaid+" THEN %s ELSE %s END", groupBy)
+	}
+	statement, err := plan.SelectStatement()
+	if suite.NoError(err) {
+		suite.Equal([]interface{}{1, true, "paid", "unpaid", true, 0, 1}, statement.Args())
+	}
+
+	plan = suite.query().GroupBy(new(string)).(*plans.QueryPlan)
+	suite.NotEmpty(plan.Errors, "GroupBy should reject pointers that aren't fields")
+}
+
+func (suite *StatementTestSuite) TestStatement_CaseEverywhere() {
+	status := gorq.CaseOf(&suite.Ref.PersonId).When(1).Then("first").When(2).Then("second").Else("other")
+	paid := gorq.When(filters.True(&suite.Ref.IsPaid)).Then(&suite.Ref.Created).Else(0)
+	plan := suite.query().
+		Where().
+		NotEqual(status, "other").
+		Greater(paid, 5).
+		SelectExpr(gorq.Length(status), &suite.Ref.MemoLength).
+		GroupBy(status).
+		GroupBy(&suite.Ref.Id).
+		OrderBy(paid, gorq.Desc).(*plans.QueryPlan)
+	statement, err := plan.SelectStatement()
+	if !suite.NoError(err) {
+		return
+	}
+	statusSql := "CASE " + suite.column("PersonId") + " WHEN %s THEN %s WHEN %s THEN %s ELSE %s END"
+	paidSql := "CASE WHEN " + suite.column("IsPaid") + " THEN " + suite.column("Created") + " ELSE %s END"
+	query := statement.Query()
+	suite.Contains(query, ","+lengthSql(suite.Map.Dialect, statusSql)+" FROM ")
+	suite.Contains(query, " WHERE ("+statusSql+"<>%s and "+paidSql+">%s)")
+	suite.True(strings.HasSuffix(query, " GROUP BY "+statusSql+", "+suite.column("Id")+" ORDER BY "+paidSql+" DESC"), "Query: %s", query)
+	statusArgs := []interface{}{1, "first", 2, "second", "other"}
+	var args []interface{}
+	args = append(args, statusArgs...)
+	args = append(args, statusArgs...)
+	args = append(args, "other", 0, 5)
+	args = append(args, statusArgs...)
+	args = append(args, 0)
+	suite.Equal(args, statement.Args())
+}
+
+// lengthSql returns the SQL that gorq.Length generates for sqlValue.
+func lengthSql(dialect gorp.Dialect, sqlValue string) string {
+	if _, isMySQL := dialect.(dialects.MySQLDialect); isMySQL {
+		return "CHAR_LENGTH(" + sqlValue + ")"
+	}
+	return "length(" + sqlValue + ")"
+}
+
+func (suite *StatementTestSuite) TestStatement_Raw() {
+	plan := suite.query().
+		Where().
+		Equal(&suite.Ref.IsPaid, true).
+		Filter(filters.Raw("? > ? + ?", &suite.Ref.Updated, &suite.Ref.Created, 5)).
+		OrderBy(gorq.Raw("coalesce(?, ?)", filters.Col(&suite.Ref.Memo), "x"), "").(*plans.QueryPlan)
+	where, args := suite.whereClause(plan)
+	suite.Equal("("+suite.column("IsPaid")+"=%s and "+suite.column("Updated")+" > "+suite.column("Created")+" + %s)"+
+		" ORDER BY coalesce("+suite.column("Memo")+", %s)", where)
+	suite.Equal([]interface{}{true, 5, "x"}, args)
+
+	plan = suite.query().Where().Filter(filters.Raw("? = ?", &suite.Ref.Memo)).(*plans.QueryPlan)
+	_, err := plan.SelectStatement()
+	suite.Error(err, "Raw SQL should require one value per placeholder")
+}
+
+// orderByClause returns the portion of plan's select statement
+// starting after "ORDER BY ".
+func (suite *StatementTestSuite) orderByClause(plan *plans.QueryPlan) string {
+	statement, err := plan.SelectStatement()
+	if !suite.NoError(err) {
+		suite.T().FailNow()
+	}
+	query := statement.Query()
+	const orderBy = " ORDER BY "
+	index := strings.Index(query, orderBy)
+	if index < 0 {
+		suite.Fail("No order by clause found", "Query: %s", query)
+		return ""
+	}
+	return query[index+len(orderBy):]
+}
+
+func (suite *StatementTestSuite) TestStatement_OrderByNulls() {
+	plan := suite.query().
+		OrderBy(&suite.Ref.Memo, gorq.NullsLast(gorq.Desc)).
+		OrderBy(gorq.When(filters.True(&suite.Ref.IsPaid)).Then(0).Else(1), gorq.NullsFirst(gorq.Asc)).
+		OrderBy(&suite.Ref.Id, "asc").(*plans.QueryPlan)
+	memo := suite.column("Memo")
+	paid := "CASE WHEN " + suite.column("IsPaid") + " THEN %s ELSE %s END"
+	id := suite.column("Id") + " ASC"
+	statement, err := plan.SelectStatement()
+	if !suite.NoError(err) {
+		return
+	}
+	switch suite.Map.Dialect.(type) {
+	case dialects.MySQLDialect:
+		suite.Equal("ISNULL("+memo+"), "+memo+" DESC, ISNULL("+paid+") DESC, "+paid+" ASC, "+id, suite.orderByClause(plan))
+		suite.Equal([]interface{}{0, 1, 0, 1}, statement.Args())
+	case dialects.SqliteDialect:
+		suite.Equal(memo+" IS NULL, "+memo+" DESC, "+paid+" IS NULL DESC, "+paid+" ASC, "+id, suite.orderByClause(plan))
+		suite.Equal([]interface{}{0, 1, 0, 1}, statement.Args())
+	default:
+		suite.Equal(memo+" DESC NULLS LAST, "+paid+" ASC NULLS FIRST, "+id, suite.orderByClause(plan))
+		suite.Equal([]interface{}{0, 1}, statement.Args())
+	}
+}
+
+// queryRecorder is a gorp.SqlExecutor that records the queries passed
+// to Select and Query instead of running them.
+type queryRecorder struct {
+	gorp.SqlExecutor
+	query string
+}
+
+func (recorder *queryRecorder) Select(i interface{}, query string, args ...interface{}) ([]interface{}, error) {
+	recorder.query = query
+	return nil, nil
+}
+
+func (recorder *queryRecorder) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	recorder.query = query
+	return nil, errors.New("queries are only recorded")
+}
+
+func (suite *StatementTestSuite) TestStatement_KeysetPageNulls() {
+	recorder := new(queryRecorder)
+	_, _, err := plans.Query(suite.Map, recorder, suite.Ref).
+		OrderBy(&suite.Ref.Memo, gorq.NullsLast(gorq.Desc)).
+		OrderBy(&suite.Ref.Id, gorq.Asc).
+		KeysetPage(nil, nil, 10)
+	if !suite.NoError(err) {
+		return
+	}
+	memo, id := suite.column("Memo"), suite.column("Id")
+	expected := memo + " DESC NULLS LAST, " + id + " ASC"
+	switch suite.Map.Dialect.(type) {
+	case dialects.MySQLDialect:
+		expected = "ISNULL(" + memo + "), " + memo + " DESC, " + id + " ASC"
+	case dialects.SqliteDialect:
+		expected = memo + " IS NULL, " + memo + " DESC, " + id + " ASC"
+	}
+	suite.Contains(recorder.query, " ORDER BY "+expected)
+}
+
+func (suite *StatementTestSuite) TestStatement_OrderByInvalidDirection() {
+	for _, direction := range []string{"ASC; DROP TABLE foo", "sideways", "DESC NULLS", "NULLS LAST ASC"} {
+		plan := suite.query().OrderBy(&suite.Ref.Id, direction).(*plans.QueryPlan)
+		suite.NotEmpty(plan.Errors, "Direction %q should be rejected", direction)
+	}
+	plan := suite.query().OrderBy(&suite.Ref.Id, " nulls first ").(*plans.QueryPlan)
+	suite.Empty(plan.Errors)
+}
+
+func (suite *StatementTestSuite) TestStatement_RowLocks() {
+	plan := suite.query().ForUpdate().(*plans.QueryPlan)
+	suite.NotEmpty(plan.Errors, "Row locks should require a transaction")
+
+	// The transaction is never used to run queries here.
+	tx := plans.Query(suite.Map, new(gorp.Transaction), suite.Ref)
+	plan = tx.Where().Equal(&suite.Ref.IsPaid, false).Limit(5).ForUpdate().SkipLocked().(*plans.QueryPlan)
+	if _, isSqlite := suite.Map.Dialect.(dialects.SqliteDialect); isSqlite {
+		suite.NotEmpty(plan.Errors, "SQLite does not support row locks")
+		return
+	}
+	statement, err := plan.SelectStatement()
+	if suite.NoError(err) {
+		suite.True(strings.HasSuffix(statement.Query(), " FOR UPDATE SKIP LOCKED"), "Query: %s", statement.Query())
+		suite.Equal([]interface{}{false, int64(5)}, statement.Args())
+	}
+
+	plan = plans.Query(suite.Map, new(gorp.Transaction), suite.Ref).ForShare(suite.Ref).NoWait().(*plans.QueryPlan)
+	statement, err = plan.SelectStatement()
+	if suite.NoError(err) {
+		suite.True(strings.HasSuffix(statement.Query(), " FOR SHARE OF "+suite.Map.Dialect.QuoteField("OverriddenInvoice")+" NOWAIT"),
+			"Query: %s", statement.Query())
+	}
+
+	plan = plans.Query(suite.Map, new(gorp.Transaction), suite.Ref).ForShare().(*plans.QueryPlan)
+	statement, err = plan.SelectStatement()
+	if suite.NoError(err) {
+		expected := " FOR SHARE"
+		if _, isMySQL := suite.Map.Dialect.(dialects.MySQLDialect); isMySQL {
+			expected = " LOCK IN SHARE MODE"
+		}
+		suite.True(strings.HasSuffix(statement.Query(), expected), "Query: %s", statement.Query())
+	}
+
+	joined := new(OverriddenInvoice)
+	plan = plans.Query(suite.Map, new(gorp.Transaction), suite.Ref).
+		Join(joined).
+		On(filters.Equal(&suite.Ref.Id, &joined.Id)).
+		ForUpdate(joined).(*plans.QueryPlan)
+	statement, err = plan.SelectStatement()
+	if suite.NoError(err) {
+		suite.True(strings.HasSuffix(statement.Query(), " FOR UPDATE OF "+suite.Map.Dialect.QuoteField("OverriddenInvoice")),
+			"Query: %s", statement.Query())
+	}
+	plan = plans.Query(suite.Map, new(gorp.Transaction), suite.Ref).ForUpdate(new(OverriddenInvoice)).(*plans.QueryPlan)
+	suite.NotEmpty(plan.Errors, "Reference structs outside of the query cannot be locked")
+
+	plan = plans.Query(suite.Map, new(gorp.Transaction), suite.Ref).ForUpdate().NoWait().SkipLocked().(*plans.QueryPlan)
+	suite.NotEmpty(plan.Errors, "NOWAIT and SKIP LOCKED should be mutually exclusive")
+	plan = plans.Query(suite.Map, new(gorp.Transaction), suite.Ref).SkipLocked().(*plans.QueryPlan)
+	suite.NotEmpty(plan.Errors, "SKIP LOCKED should require a locking clause")
+	plan = plans.Query(suite.Map, new(gorp.Transaction), suite.Ref).ForUpdate(new(Invoice)).(*plans.QueryPlan)
+	suite.NotEmpty(plan.Errors, "Tables outside of the query cannot be locked")
+}
+
+func (suite *StatementTestSuite) TestStatement_TimeBucketsUTC() {
+	if _, ok := suite.Map.Dialect.(gorp.PostgresDialect); !ok {
+		suite.T().Skip("Only PostgreSQL truncates in the session time zone")
+	}
+	recorder := new(queryRecorder)
+	now := time.Now()
+	plans.Query(suite.Map, recorder, suite.Ref).
+		TimeBuckets(&suite.Ref.Created, "day").
+		Fill(now.Add(-48*time.Hour), now).
+		Count()
+	created := suite.column("Created")
+	suite.Contains(recorder.query, "date_trunc('day', "+created+" AT TIME ZONE 'UTC') AS bucket_start")
+	suite.Contains(recorder.query, "generate_series(date_trunc('day', CAST($1 AS timestamptz) AT TIME ZONE 'UTC'), "+
+		"CAST($2 AS timestamptz) AT TIME ZONE 'UTC', INTERVAL '1 day')")
+	suite.Contains(recorder.query, "WHERE series.bucket_start < CAST($3 AS timestamptz) AT TIME ZONE 'UTC'")
+}
@@ -54,7 +54,8 @@ func (structMap structColumnMap) LocateTableAndColumn(fieldPtr interface{}) (str
 }
 
 // fieldMapForPointer takes a pointer to a struct field and returns
-// the fieldColumnMap for that struct field.
+// the fieldColumnMap for that struct field.  If no field matches
+// fieldPtr, the error will be a *FieldNotFoundError.
 func (structMap structColumnMap) fieldMapForPointer(fieldPtr interface{}) (*fieldColumnMap, error) {
 	for _, fieldMap := range structMap {
 		if fieldMap.addr == fieldPtr {
@@ -64,7 +65,35 @@ func (structMap structColumnMap) fieldMapForPointer(fieldPtr interface{}) (*fiel
 			return &fieldMap, nil
 		}
 	}
-	fieldPtrVal := reflect.ValueOf(fieldPtr)
+	return nil, &FieldNotFoundError{FieldPtr: fieldPtr}
+}
+
+// transientFieldMapForPointer is like fieldMapForPointer, except
+// that it only matches transient fields.
+func (structMap structColumnMap) transientFieldMapForPointer(fieldPtr interface{}) (*fieldColumnMap, error) {
+	for _, fieldMap := range structMap {
+		if fieldMap.addr == fieldPtr {
+			if !fieldMap.column.Transient {
+				return nil, errors.New("gorp: Only transient fields can be populated by expressions")
+			}
+			return &fieldMap, nil
+		}
+	}
+	return nil, &FieldNotFoundError{FieldPtr: fieldPtr}
+}
+
+// A FieldNotFoundError is returned when a pointer that was expected
+// to point to a field in one of the reference structs for a query
+// could not be found.
+type FieldNotFoundError struct {
+	FieldPtr interface{}
+}
+
+func (err *FieldNotFoundError) Error() string {
+	fieldPtrVal := reflect.ValueOf(err.FieldPtr)
+	if fieldPtrVal.Kind() != reflect.Ptr || fieldPtrVal.IsNil() {
+		return fmt.Sprintf("gorp: Cannot find a field matching the passed in pointer %v", err.FieldPtr)
+	}
 	addr, value := fieldPtrVal.Pointer(), fieldPtrVal.Elem().Interface()
-	return nil, fmt.Errorf("gorp: Cannot find a field matching the passed in pointer %d (value %v)", addr, value)
+	return fmt.Sprintf("gorp: Cannot find a field matching the passed in pointer %d (value %v)", addr, value)
 }
@@ -0,0 +1,229 @@
+package plans
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nelsam/gorq/dialects"
+	"github.com/nelsam/gorq/filters"
+	"github.com/nelsam/gorq/interfaces"
+)
+
+// truncateTime truncates t (in UTC) to the start of unit.
+func truncateTime(t time.Time, unit string) time.Time {
+	t = t.UTC()
+	switch unit {
+	case "year":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "week":
+		// time.Weekday starts on Sunday, but weeks start on Monday.
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "minute":
+		return t.Truncate(time.Minute)
+	default:
+		return t.Truncate(time.Second)
+	}
+}
+
+// nextBucket returns the start of the bucket after start.
+func nextBucket(start time.Time, unit string) time.Time {
+	switch unit {
+	case "year":
+		return start.AddDate(1, 0, 0)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "day":
+		return start.AddDate(0, 0, 1)
+	case "hour":
+		return start.Add(time.Hour)
+	case "minute":
+		return start.Add(time.Minute)
+	default:
+		return start.Add(time.Second)
+	}
+}
+
+// bucketTime converts a bucket's start time, as returned by the
+// database driver, to a time.Time.  Text values (e.g. from SQLite) are
+// parsed as UTC.
+func bucketTime(value interface{}) (time.Time, error) {
+	switch src := value.(type) {
+	case time.Time:
+		return src, nil
+	case []byte:
+		return bucketTime(string(src))
+	case string:
+		return time.ParseInLocation("2006-01-02 15:04:05", src, time.UTC)
+	}
+	return time.Time{}, fmt.Errorf("gorp: Cannot convert %T to a bucket time", value)
+}
+
+// utcSuffix converts a PostgreSQL timestamptz value to a timestamp
+// in UTC.
+const utcSuffix = " AT TIME ZONE 'UTC'"
+
+// timeBucketQuery is the interfaces.TimeBucketQuery returned by
+// TimeBuckets.
+type timeBucketQuery struct {
+	plan     *QueryPlan
+	fieldPtr interface{}
+	unit     string
+	fill     bool
+	from, to time.Time
+}
+
+// TimeBuckets returns an interfaces.TimeBucketQuery that groups the
+// rows matching this query plan by timeFieldPtr, truncated to unit
+// (see filters.DateTrunc), and aggregates each group.  Any limit, offset,
+// order by, or group by clauses on the query are ignored.
+//
+//     buckets, err := dbMap.Query(ref).
+//         Where().
+//         GreaterOrEqual(&ref.CreatedAt, start).
+//         Less(&ref.CreatedAt, end).
+//         TimeBuckets(&ref.CreatedAt, "hour").
+//         Fill(start, end).
+//         Sum(&ref.Bytes)
+//
+// Buckets are truncated in UTC for every dialect.  When empty buckets
+// are filled, PostgreSQL uses generate_series; other dialects fill
+// them in after the query runs.
+func (plan *QueryPlan) TimeBuckets(timeFieldPtr interface{}, unit string) interfaces.TimeBucketQuery {
+	return &timeBucketQuery{
+		plan:     plan,
+		fieldPtr: timeFieldPtr,
+		unit:     strings.ToLower(unit),
+	}
+}
+
+func (q *timeBucketQuery) Fill(from, to time.Time) interfaces.TimeBucketQuery {
+	q.fill, q.from, q.to = true, from, to
+	return q
+}
+
+func (q *timeBucketQuery) Count() ([]interfaces.TimeBucket, error) {
+	return q.Aggregate(filters.FormatWrapper{
+		Format: func(...string) string {
+			return "COUNT(*)"
+		},
+	})
+}
+
+func (q *timeBucketQuery) Sum(fieldPtrOrWrapper interface{}) ([]interfaces.TimeBucket, error) {
+	return q.Aggregate(filters.Function("SUM", fieldPtrOrWrapper))
+}
+
+func (q *timeBucketQuery) Aggregate(aggregate interface{}) ([]interfaces.TimeBucket, error) {
+	plan := q.plan.withoutGrouping()
+	if len(plan.Errors) > 0 {
+		return nil, plan.Errors[0]
+	}
+	if _, err := plan.colMap.fieldMapForPointer(q.fieldPtr); err != nil {
+		return nil, err
+	}
+	_, isMySQL := plan.dbMap.Dialect.(dialects.MySQLDialect)
+	_, isSqlite := plan.dbMap.Dialect.(dialects.SqliteDialect)
+	isPostgres := !isMySQL && !isSqlite
+	fillInSQL := q.fill && isPostgres
+
+	var bucketValue interface{} = q.fieldPtr
+	if isPostgres {
+		// date_trunc uses the session's time zone for timestamptz
+		// values, so convert to UTC first to match other dialects.
+		bucketValue = filters.FormatWrapper{
+			Values: []interface{}{q.fieldPtr},
+			Format: func(sqlValues ...string) string {
+				return sqlValues[0] + utcSuffix
+			},
+		}
+	}
+
+	statement := new(Statement)
+	if fillInSQL {
+		// The series is a bound value, so it has to be cast.
+		interval := "INTERVAL '1 " + q.unit + "'"
+		statement.query.WriteString("SELECT series.bucket_start,grouped.bucket_value FROM generate_series(date_trunc('" +
+			q.unit + "', CAST(" + BindVarPlaceholder + " AS timestamptz)" + utcSuffix + "), CAST(" + BindVarPlaceholder +
+			" AS timestamptz)" + utcSuffix + ", " + interval + ") AS series(bucket_start) LEFT JOIN (")
+		statement.args = append(statement.args, q.from, q.to)
+	}
+	statement.query.WriteString("SELECT ")
+	args, bucket, err := plan.argOrColumn(filters.DateTrunc(q.unit, bucketValue))
+	if err != nil {
+		return nil, err
+	}
+	statement.query.WriteString(bucket + " AS bucket_start,")
+	statement.args = append(statement.args, args...)
+	args, value, err := plan.argOrColumn(aggregate)
+	if err != nil {
+		return nil, err
+	}
+	statement.query.WriteString(value + " AS bucket_value")
+	statement.args = append(statement.args, args...)
+	if err := plan.addSelectSuffix(statement); err != nil {
+		return nil, err
+	}
+	// Grouping by position avoids binding the arguments for the
+	// bucket a second time.
+	statement.query.WriteString(" GROUP BY 1")
+	if fillInSQL {
+		statement.query.WriteString(") AS grouped ON grouped.bucket_start = series.bucket_start WHERE series.bucket_start < CAST(" + BindVarPlaceholder + " AS timestamptz)" + utcSuffix)
+		statement.args = append(statement.args, q.to)
+	}
+	statement.query.WriteString(" ORDER BY 1")
+
+	bindVars := plan.bindVars(statement)
+	rows, err := plan.executor.Query(statement.Query(bindVars...), statement.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var buckets []interfaces.TimeBucket
+	for rows.Next() {
+		var (
+			start interface{}
+			value sql.NullFloat64
+		)
+		if err := rows.Scan(&start, &value); err != nil {
+			return nil, err
+		}
+		startTime, err := bucketTime(start)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, interfaces.TimeBucket{Start: startTime, Value: value.Float64})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if q.fill && !fillInSQL {
+		buckets = q.fillBuckets(buckets)
+	}
+	return buckets, nil
+}
+
+// fillBuckets returns buckets with an empty bucket added for every
+// bucket between q.from and q.to that is missing.  Buckets outside of
+// that range are dropped.
+func (q *timeBucketQuery) fillBuckets(buckets []interfaces.TimeBucket) []interfaces.TimeBucket {
+	values := make(map[int64]float64, len(buckets))
+	for _, bucket := range buckets {
+		values[bucket.Start.Unix()] = bucket.Value
+	}
+	var filled []interfaces.TimeBucket
+	for start := truncateTime(q.from, q.unit); start.Before(q.to); start = nextBucket(start, q.unit) {
+		filled = append(filled, interfaces.TimeBucket{Start: start, Value: values[start.Unix()]})
+	}
+	return filled
+}
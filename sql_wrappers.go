@@ -46,6 +46,17 @@ func Count(value interface{}) filters.SqlWrapper {
 	}
 }
 
+// Grouping returns a filters.SqlWrapper that wraps the passed in
+// value in an sql GROUPING() call, which is 1 for subtotal rows
+// generated by GroupByRollup, GroupByCube, or GroupingSets, and 0
+// otherwise.
+func Grouping(value interface{}) filters.SqlWrapper {
+	return functionWrapper{
+		actualValue:  value,
+		functionName: "GROUPING",
+	}
+}
+
 // whenValue represents a single "WHEN ... THEN ..." pair in a CASE
 // WHEN clause.
 type whenValue struct {
@@ -139,3 +150,103 @@ func When(comparison filters.Filter) Thener {
 		whenValues: []whenValue{{when: comparison}},
 	}
 }
+
+// A ValueCase is a filters.MultiSqlWrapper for the simple form of
+// CASE, which compares a single value against each WHEN value:
+// CASE value WHEN ... THEN ... ELSE ... END.
+type ValueCase interface {
+	filters.MultiSqlWrapper
+	When(interface{}) ValueThener
+	Else(interface{}) filters.MultiSqlWrapper
+}
+
+// ValueThener is a ValueCase that is in a state where a WHEN value
+// has been supplied, so it needs a corresponding THEN expression.
+type ValueThener interface {
+	Then(interface{}) ValueCase
+}
+
+// valueWhen represents a single "WHEN ... THEN ..." pair in a simple
+// CASE expression.
+type valueWhen struct {
+	when interface{}
+	then interface{}
+}
+
+// A SimpleCaseWhen is a type to hold details about a simple CASE
+// expression.
+type SimpleCaseWhen struct {
+	value      interface{}
+	whenValues []valueWhen
+	elseValue  interface{}
+}
+
+// ActualValues implements filters.MultiSqlWrapper.ActualValues.
+func (c *SimpleCaseWhen) ActualValues() []interface{} {
+	values := make([]interface{}, 0, 2*len(c.whenValues)+2)
+	values = append(values, c.value)
+	for _, whenVal := range c.whenValues {
+		values = append(values, whenVal.when, whenVal.then)
+	}
+	if c.elseValue != nil {
+		values = append(values, c.elseValue)
+	}
+	return values
+}
+
+// WrapSql implements filters.MultiSqlWrapper.WrapSql.
+func (c *SimpleCaseWhen) WrapSql(values ...string) string {
+	buf := bytes.NewBufferString("CASE ")
+	buf.WriteString(values[0])
+	idx := 1
+	for range c.whenValues {
+		buf.WriteString(" WHEN ")
+		buf.WriteString(values[idx])
+		buf.WriteString(" THEN ")
+		buf.WriteString(values[idx+1])
+		idx += 2
+	}
+	if c.elseValue != nil {
+		buf.WriteString(" ELSE ")
+		buf.WriteString(values[idx])
+	}
+	buf.WriteString(" END")
+	return buf.String()
+}
+
+// When implements ValueCase.When.
+func (c *SimpleCaseWhen) When(value interface{}) ValueThener {
+	c.whenValues = append(c.whenValues, valueWhen{
+		when: value,
+	})
+	return c
+}
+
+// Then implements ValueThener.Then.
+func (c *SimpleCaseWhen) Then(value interface{}) ValueCase {
+	c.whenValues[len(c.whenValues)-1].then = value
+	return c
+}
+
+// Else implements ValueCase.Else.
+func (c *SimpleCaseWhen) Else(value interface{}) filters.MultiSqlWrapper {
+	c.elseValue = value
+	return c
+}
+
+// CaseOf constructs a ValueCase for a simple CASE expression, which
+// compares value against each WHEN value in turn.  Like the result
+// of When, it can be used anywhere a field pointer can: in select
+// expressions, filters, OrderBy, GroupBy, Assign, etc.  Note that a
+// nil WHEN value will never match, since NULL is not equal to
+// anything; use When(filters.Null(...)) for that.
+//
+// Example usage:
+//
+//     gorq.CaseOf(&foo.Status).When("new").Then(0).When("open").Then(1).Else(2)
+//
+func CaseOf(value interface{}) ValueCase {
+	return &SimpleCaseWhen{
+		value: value,
+	}
+}
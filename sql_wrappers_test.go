@@ -13,3 +13,13 @@ func TestLower(t *testing.T) {
 	assert.Equal(t, val, wrapper.ActualValue())
 	assert.Equal(t, fmt.Sprintf("lower(%s)", val), wrapper.WrapSql(val))
 }
+
+func TestCaseOf(t *testing.T) {
+	wrapper := CaseOf("status").When("new").Then(0).When("open").Then(1).Else(2)
+	assert.Equal(t, []interface{}{"status", "new", 0, "open", 1, 2}, wrapper.ActualValues())
+	assert.Equal(t, "CASE s WHEN a THEN b WHEN c THEN d ELSE e END", wrapper.WrapSql("s", "a", "b", "c", "d", "e"))
+
+	noElse := CaseOf("status").When("new").Then(0)
+	assert.Equal(t, []interface{}{"status", "new", 0}, noElse.ActualValues())
+	assert.Equal(t, "CASE s WHEN a THEN b END", noElse.WrapSql("s", "a", "b"))
+}
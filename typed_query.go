@@ -0,0 +1,147 @@
+//go:build go1.23
+// +build go1.23
+
+package gorq
+
+import (
+	"iter"
+
+	"github.com/nelsam/gorq/filters"
+	"github.com/nelsam/gorq/interfaces"
+)
+
+// A Querier is a type that can generate queries, like *DbMap and
+// *Transaction.
+type Querier interface {
+	Query(target interface{}) interfaces.Query
+}
+
+// A TypedQuery is a query with a reference struct of type T.  Its
+// Select, First, One, and Iterate methods return T values instead of
+// interface{} values.
+//
+// Where, OrderBy, Limit, and Offset return the TypedQuery, so they
+// can be chained through to the final method call:
+//
+//     q := gorq.From[Invoice](dbMap)
+//     ref := q.Ref()
+//     invoices, err := q.Where(filters.Equal(&ref.Memo, "foo")).
+//         OrderBy(&ref.Created, gorq.Desc).
+//         Select()
+//
+// Other query construction methods (like Join or GroupBy) return the
+// usual interfaces types.  Since they modify the query in place, call
+// them without chaining, then continue with q.
+//
+// TypedQuery requires Go 1.23 or newer.
+type TypedQuery[T any] struct {
+	interfaces.Query
+	ref *T
+}
+
+// From returns a TypedQuery for T, using a new T value as the
+// reference struct.  T must be a struct type that has been
+// registered with AddTable.
+func From[T any](querier Querier) *TypedQuery[T] {
+	ref := new(T)
+	return &TypedQuery[T]{Query: querier.Query(ref), ref: ref}
+}
+
+// Ref returns the reference struct for q.  Pass the addresses of its
+// fields to query construction methods to refer to columns, just
+// like you would with the target passed to DbMap.Query.
+func (q *TypedQuery[T]) Ref() *T {
+	return q.ref
+}
+
+// Where adds filters to the where clause of q and returns q.
+func (q *TypedQuery[T]) Where(filters ...filters.Filter) *TypedQuery[T] {
+	q.Query.Where(filters...)
+	return q
+}
+
+// OrderBy orders the results of q by a field of the reference struct
+// and returns q.
+func (q *TypedQuery[T]) OrderBy(fieldPtr interface{}, direction string) *TypedQuery[T] {
+	q.Query.OrderBy(fieldPtr, direction)
+	return q
+}
+
+// Limit limits the results of q to a maximum length and returns q.
+func (q *TypedQuery[T]) Limit(limit int64) *TypedQuery[T] {
+	q.Query.Limit(limit)
+	return q
+}
+
+// Offset sets the starting point of the results of q and returns q.
+func (q *TypedQuery[T]) Offset(offset int64) *TypedQuery[T] {
+	q.Query.Offset(offset)
+	return q
+}
+
+// Select runs q as a SELECT statement and returns the resulting rows.
+func (q *TypedQuery[T]) Select() ([]T, error) {
+	var results []T
+	if err := q.Query.SelectToTarget(&results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// First runs q as a SELECT statement with a limit of 1 and returns
+// the resulting row.  If there are no resulting rows, sql.ErrNoRows
+// will be returned.
+func (q *TypedQuery[T]) First() (T, error) {
+	return typedResult[T](q.Query.First())
+}
+
+// One runs q as a SELECT statement and returns the resulting row.
+// If there are no resulting rows, sql.ErrNoRows will be returned;
+// if there is more than one, plans.ErrMultipleRows will be returned.
+func (q *TypedQuery[T]) One() (T, error) {
+	return typedResult[T](q.Query.One())
+}
+
+func typedResult[T any](result interface{}, err error) (T, error) {
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return *result.(*T), nil
+}
+
+// Iterate runs q as a SELECT statement and returns an iterator over
+// the resulting rows.  Rows are only loaded into memory one at a
+// time.  If an error is encountered, it will be yielded and
+// iteration will stop.
+//
+//     for invoice, err := range q.Iterate() {
+//         if err != nil {
+//             return err
+//         }
+//         // Do something with invoice
+//     }
+func (q *TypedQuery[T]) Iterate() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		rows, err := q.Query.Iterate()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var row T
+			if err := rows.Scan(&row); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
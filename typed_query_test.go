@@ -0,0 +1,85 @@
+//go:build go1.23
+// +build go1.23
+
+package gorq_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/go-gorp/gorp"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nelsam/gorq"
+	"github.com/nelsam/gorq/filters"
+	"github.com/stretchr/testify/assert"
+)
+
+type Widget struct {
+	Id   int64
+	Name string
+}
+
+func newWidgetMap(t *testing.T) *gorq.DbMap {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	// Every connection to :memory: is a new database.
+	db.SetMaxOpenConns(1)
+	dbMap := &gorq.DbMap{DbMap: gorp.DbMap{Db: db, Dialect: gorp.SqliteDialect{}}}
+	dbMap.AddTable(Widget{}).SetKeys(true, "Id")
+	if !assert.NoError(t, dbMap.CreateTables()) {
+		t.FailNow()
+	}
+	for _, name := range []string{"foo", "bar", "baz"} {
+		if !assert.NoError(t, dbMap.Insert(&Widget{Name: name})) {
+			t.FailNow()
+		}
+	}
+	return dbMap
+}
+
+func TestTypedQuery(t *testing.T) {
+	dbMap := newWidgetMap(t)
+	defer dbMap.Db.Close()
+
+	q := gorq.From[Widget](dbMap)
+	ref := q.Ref()
+	widgets, err := q.Where(filters.NotEqual(&ref.Name, "bar")).
+		OrderBy(&ref.Name, gorq.Asc).
+		Select()
+	if assert.NoError(t, err) && assert.Equal(t, 2, len(widgets)) {
+		assert.Equal(t, "baz", widgets[0].Name)
+		assert.Equal(t, "foo", widgets[1].Name)
+	}
+
+	first, err := q.First()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "baz", first.Name)
+	}
+
+	var names []string
+	for widget, err := range q.Iterate() {
+		if !assert.NoError(t, err) {
+			break
+		}
+		names = append(names, widget.Name)
+	}
+	assert.Equal(t, []string{"baz", "foo"}, names)
+
+	_, err = q.One()
+	assert.Error(t, err, "One should fail when more than one row matches")
+
+	empty := gorq.From[Widget](dbMap)
+	_, err = empty.Where(filters.Equal(&empty.Ref().Name, "qux")).First()
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	paged := gorq.From[Widget](dbMap)
+	page, err := paged.OrderBy(&paged.Ref().Name, gorq.Asc).
+		Limit(1).
+		Offset(1).
+		Select()
+	if assert.NoError(t, err) && assert.Equal(t, 1, len(page)) {
+		assert.Equal(t, "baz", page[0].Name)
+	}
+}